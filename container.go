@@ -2,11 +2,21 @@ package testcontainers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/pkg/archive"
@@ -22,7 +32,7 @@ type DeprecatedContainer interface {
 	GetHostEndpoint(ctx context.Context, port string) (string, string, error)
 	GetIPAddress(ctx context.Context) (string, error)
 	LivenessCheckPorts(ctx context.Context) (nat.PortSet, error)
-	Terminate(ctx context.Context) error
+	Terminate(ctx context.Context, opts ...TerminateOption) error
 }
 
 // ContainerProvider allows the creation of containers on an arbitrary system
@@ -44,11 +54,20 @@ type Container interface {
 	Ports(context.Context) (nat.PortMap, error)                     // get all exposed ports
 	SessionID() string                                              // get session id
 	IsRunning() bool
-	Start(context.Context) error                 // start the container
-	Stop(context.Context, *time.Duration) error  // stop the container
-	Terminate(context.Context) error             // terminate the container
-	Logs(context.Context) (io.ReadCloser, error) // Get logs of the container
+	PortEndpointWithOptions(context.Context, nat.Port, EndpointOptions) (string, error)
+	ConnectToNetwork(ctx context.Context, networkName string, aliases ...string) error
+	DisconnectFromNetwork(ctx context.Context, networkName string) error
+	Inspect(ctx context.Context) (*types.ContainerJSON, error)
+	Commit(ctx context.Context, imageRef string, opts ...CommitOption) (string, error)
+	Update(ctx context.Context, config container.UpdateConfig) error
+	Start(context.Context) error                         // start the container
+	Stop(context.Context, *time.Duration) error          // stop the container
+	Restart(context.Context, *time.Duration) error       // restart the container, waiting for it to be ready again
+	Terminate(context.Context, ...TerminateOption) error // terminate the container
+	Logs(context.Context) (io.ReadCloser, error)         // Get logs of the container
+	LogsWithOptions(context.Context, ...LogsOption) (io.ReadCloser, error)
 	FollowOutput(LogConsumer)
+	RemoveConsumer(LogConsumer) // stop following the output with a consumer, without stopping the log producer
 	StartLogProducer(context.Context) error
 	StopLogProducer() error
 	Name(context.Context) (string, error)                        // get container name
@@ -56,12 +75,29 @@ type Container interface {
 	Networks(context.Context) ([]string, error)                  // get container networks
 	NetworkAliases(context.Context) (map[string][]string, error) // get container network aliases for a network
 	Exec(ctx context.Context, cmd []string, options ...tcexec.ProcessOption) (int, io.Reader, error)
+	ExecWithOptions(ctx context.Context, cmd []string, options ExecOptions) (int, io.Reader, io.Reader, error)
 	ContainerIP(context.Context) (string, error)    // get container ip
 	ContainerIPs(context.Context) ([]string, error) // get all container IPs
+	NetworkIP(ctx context.Context, networkName string) (string, error)
+	PortForward(ctx context.Context, containerPort nat.Port) (string, func(), error) // forward a local address to a port
 	CopyToContainer(ctx context.Context, fileContent []byte, containerFilePath string, fileMode int64) error
+	CopyReaderToContainer(ctx context.Context, reader io.Reader, size int64, containerFilePath string, fileMode int64) error
 	CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64) error
 	CopyFileToContainer(ctx context.Context, hostFilePath string, containerFilePath string, fileMode int64) error
 	CopyFileFromContainer(ctx context.Context, filePath string) (io.ReadCloser, error)
+	Stats(context.Context) (<-chan ContainerStats, error) // stream the resources used by the container
+}
+
+// IsRunning returns whether the container is running, refreshing its state through State, unlike
+// Container.IsRunning which returns the state last known by Testcontainers. It returns false if the
+// state of the container cannot be inspected, e.g. because it was removed
+func IsRunning(ctx context.Context, c Container) bool {
+	state, err := c.State(ctx)
+	if err != nil || state == nil {
+		return false
+	}
+
+	return state.Running
 }
 
 // ImageBuildInfo defines what is needed to build an image
@@ -72,58 +108,130 @@ type ImageBuildInfo interface {
 	ShouldBuildImage() bool                      // return true if the image needs to be built
 	GetBuildArgs() map[string]*string            // return the environment args used to build the from Dockerfile
 	GetAuthConfigs() map[string]types.AuthConfig // return the auth configs to be able to pull from an authenticated docker registry
+	ShouldUseBuildCache() bool                   // return false if the layers cached by previous builds must not be reused
+	ShouldPullParent() bool                      // return true if the base images must be pulled even if they exist locally
+	GetBuildLogWriter() io.Writer                // return the writer of the decoded build output
+}
+
+// ImageBuildSecrets is implemented by the ImageBuildInfo providing BuildKit secrets to the build,
+// which are mounted by the RUN --mount=type=secret instructions without being stored in the image
+type ImageBuildSecrets interface {
+	GetBuildSecrets() map[string]string // the contents of the secrets by ID
+}
+
+// EndpointOptions represents the parts of an endpoint besides the host and the port
+type EndpointOptions struct {
+	Scheme string // e.g. http, no scheme is added when empty
+	Path   string // e.g. /healthz, the leading slash is optional
+}
+
+// ExecOptions represents the parameters used to execute a command in a running container
+type ExecOptions struct {
+	Env         map[string]string // environment variables added to the ones of the container
+	WorkingDir  string            // defaults to the working directory of the container
+	User        string            // for specifying the user to run as, defaults to the user of the container
+	AttachStdin bool              // the command reads an empty standard input instead of none, unless Stdin is set
+	Stdin       io.Reader         // streamed to the standard input of the command, e.g. a SQL script
 }
 
 // FromDockerfile represents the parameters needed to build an image from a Dockerfile
 // rather than using a pre-built one
 type FromDockerfile struct {
 	Context        string                      // the path to the context of of the docker build
-	ContextArchive io.Reader                   // the tar archive file to send to docker that contains the build context
+	ContextArchive io.Reader                   // the tar archive file to send to docker that contains the build context, instead of Context
 	Dockerfile     string                      // the path from the context to the Dockerfile for the image, defaults to "Dockerfile"
 	BuildArgs      map[string]*string          // enable user to pass build args to docker daemon
 	PrintBuildLog  bool                        // enable user to print build log
+	BuildLogWriter io.Writer                   // receives the build output live, e.g. the layer steps and errors, takes precedence over PrintBuildLog
 	AuthConfigs    map[string]types.AuthConfig // enable auth configs to be able to pull from an authenticated docker registry
+	NoCache        bool                        // do not reuse the layers cached by previous builds, e.g. for reproducible CI builds
+	PullParent     bool                        // always pull the base images, even if they exist locally
+	Secrets        map[string]string           // contents of the BuildKit secrets by ID, for RUN --mount=type=secret,id=<ID>
 }
 
+// ContainerFile is a file copied to the container after it is created, and before it is started,
+// so that the entrypoint can read it, e.g. a configuration file
 type ContainerFile struct {
 	HostFilePath      string
+	Reader            io.Reader // the content of the file, instead of HostFilePath, e.g. a generated configuration
 	ContainerFilePath string
 	FileMode          int64
 }
 
+// GPURequest requests GPUs of the Docker host for a container, as the --gpus flag of docker run, e.g. for CUDA
+// containers. It needs the NVIDIA Container Toolkit on the Docker host
+type GPURequest struct {
+	Count        int      // number of GPUs, -1 for all of them, cannot be set with DeviceIDs
+	DeviceIDs    []string // indexes or UUIDs of the GPUs, cannot be set with Count
+	Capabilities []string // driver capabilities added to gpu, e.g. compute or utility
+}
+
+// deviceRequest returns the Docker device request of the GPU request
+func (g GPURequest) deviceRequest() container.DeviceRequest {
+	return container.DeviceRequest{
+		Count:        g.Count,
+		DeviceIDs:    g.DeviceIDs,
+		Capabilities: [][]string{append([]string{"gpu"}, g.Capabilities...)},
+	}
+}
+
+// ContainerHook is a hook executed at a point of the lifecycle of a container
+type ContainerHook func(ctx context.Context, container Container) error
+
+// ContainerLifecycleHooks are the hooks executed by the provider during the lifecycle of a container, e.g. to create
+// a database schema once the container is ready. The hooks are executed in order, and the first error is returned
+type ContainerLifecycleHooks struct {
+	PostStarts    []ContainerHook // executed once the container is started and ready, an error fails the start
+	PreTerminates []ContainerHook // executed before the container is terminated, an error fails the termination
+}
+
 // ContainerRequest represents the parameters used to get a running container
 type ContainerRequest struct {
 	FromDockerfile
-	Image           string
-	Entrypoint      []string
-	Env             map[string]string
-	ExposedPorts    []string // allow specifying protocol info
-	Cmd             []string
-	Labels          map[string]string
-	Mounts          ContainerMounts
-	Tmpfs           map[string]string
-	RegistryCred    string
-	WaitingFor      wait.Strategy
-	Name            string // for specifying container name
-	Hostname        string
-	ExtraHosts      []string
-	Privileged      bool                // for starting privileged container
-	Networks        []string            // for specifying network names
-	NetworkAliases  map[string][]string // for specifying network aliases
-	NetworkMode     container.NetworkMode
-	Resources       container.Resources
-	Files           []ContainerFile   // files which will be copied when container starts
-	User            string            // for specifying uid:gid
-	SkipReaper      bool              // indicates whether we skip setting up a reaper for this
-	ReaperImage     string            // Deprecated: use WithImageName ContainerOption instead. Alternative reaper image
-	ReaperOptions   []ContainerOption // options for the reaper
-	AutoRemove      bool              // if set to true, the container will be removed from the host when stopped
-	AlwaysPullImage bool              // Always pull image
-	ImagePlatform   string            // ImagePlatform describes the platform which the image runs on.
-	Binds           []string
-	ShmSize         int64    // Amount of memory shared with the host (in bytes)
-	CapAdd          []string // Add Linux capabilities
-	CapDrop         []string // Drop Linux capabilities
+	Image            string
+	Entrypoint       []string
+	Env              map[string]string
+	ExposedPorts     []string // allow specifying protocol info
+	Cmd              []string
+	Labels           map[string]string
+	Mounts           ContainerMounts
+	Tmpfs            map[string]string // tmpfs mounts by target path, with options in the rw,size=64m,mode=1777 form
+	ReadOnlyRootfs   bool              // mounts the root filesystem as read only, writable paths need Tmpfs or Mounts
+	RegistryCred     string
+	StartupCommand   []string // executed once the container is started, before its wait strategy, must exit with code 0
+	WaitingFor       wait.Strategy
+	LifecycleHooks   ContainerLifecycleHooks
+	Name             string              // for specifying container name
+	DependsOn        []string            // names of the requests started before this one by StartContainers
+	Hostname         string              // for specifying the container hostname, Docker's default is the short container ID
+	ExtraHosts       []string            // entries for /etc/hosts, in the name:ip form, where ip can be host-gateway
+	Privileged       bool                // for starting privileged container
+	Networks         []string            // for specifying network names
+	NetworkAliases   map[string][]string // for specifying network aliases
+	IPv4Addresses    map[string]string   // for specifying a static IPv4 address per network name, in the subnet of the network
+	NetworkMode      container.NetworkMode
+	RestartPolicy    container.RestartPolicy
+	GPUs             *GPURequest
+	Resources        container.Resources // limits, e.g. Memory in bytes and NanoCPUs in units of 10^-9 CPUs, unlimited when zero
+	Files            []ContainerFile     // files which will be copied when container starts
+	Secrets          map[string]string   // contents of the files written to /run/secrets/<name> before the container starts
+	User             string              // for specifying the user to run as: uid, uid:gid or user:group
+	WorkingDir       string              // working directory of the main process and of the execs, defaults to the one of the image
+	StopSignal       string              // signal sent by Stop and Terminate, e.g. SIGINT, defaults to the one of the image
+	LogDumpDir       string              // directory where the logs are written when the container fails to start or exits with a non-zero code
+	SkipReaper       bool                // indicates whether we skip setting up a reaper for this
+	ReaperImage      string              // Deprecated: use WithImageName ContainerOption instead. Alternative reaper image
+	ReaperOptions    []ContainerOption   // options for the reaper
+	AutoRemove       bool                // if set to true, the container will be removed from the host when stopped
+	AlwaysPullImage  bool                // Always pull image, same as PullAlways
+	PullPolicy       PullPolicy          // when to pull the image, PullIfNotPresent by default
+	VerifyDigest     bool                // verifies that the local image has the digest pinned in Image, in the name@sha256:... form
+	ImagePullRetries int                 // retries of a pull failing with a transient registry error, 2 when zero (3 attempts), none when negative
+	ImagePlatform    string              // ImagePlatform describes the platform which the image runs on, in the os/arch[/variant] form, e.g. linux/amd64
+	Binds            []string
+	ShmSize          int64    // Size of /dev/shm in bytes, the Docker default (64MB) is used when zero
+	CapAdd           []string // Add Linux capabilities
+	CapDrop          []string // Drop Linux capabilities
 }
 
 type (
@@ -132,8 +240,10 @@ type (
 
 	// GenericProviderOptions defines options applicable to all providers
 	GenericProviderOptions struct {
-		Logger         Logging
-		DefaultNetwork string
+		Logger              Logging
+		DefaultNetwork      string
+		DefaultLabels       map[string]string
+		DefaultWaitStrategy wait.Strategy
 	}
 
 	// GenericProviderOption defines a common interface to modify GenericProviderOptions
@@ -150,10 +260,61 @@ func (f GenericProviderOptionFunc) ApplyGenericTo(opts *GenericProviderOptions)
 	f(opts)
 }
 
+// WithDefaultLabels is a generic option that implements GenericProviderOption, DockerProviderOption
+// It adds the given labels to every container created by the provider, e.g. a build ID for accounting in a shared CI.
+// The labels of a request take precedence, and the labels reserved by Testcontainers cannot be set
+func WithDefaultLabels(labels map[string]string) DefaultLabelsOption {
+	return DefaultLabelsOption{
+		labels: labels,
+	}
+}
+
+type DefaultLabelsOption struct {
+	labels map[string]string
+}
+
+func (o DefaultLabelsOption) ApplyGenericTo(opts *GenericProviderOptions) {
+	opts.DefaultLabels = o.labels
+}
+
+func (o DefaultLabelsOption) ApplyDockerTo(opts *DockerProviderOptions) {
+	opts.DefaultLabels = o.labels
+}
+
+// WithDefaultWaitStrategy is a generic option that implements GenericProviderOption, DockerProviderOption
+// It sets the wait strategy of the containers created by the provider from a request without WaitingFor,
+// e.g. a log or port based strategy shared by a whole test suite. The WaitingFor of a request always takes precedence
+func WithDefaultWaitStrategy(strategy wait.Strategy) DefaultWaitStrategyOption {
+	return DefaultWaitStrategyOption{
+		strategy: strategy,
+	}
+}
+
+type DefaultWaitStrategyOption struct {
+	strategy wait.Strategy
+}
+
+func (o DefaultWaitStrategyOption) ApplyGenericTo(opts *GenericProviderOptions) {
+	opts.DefaultWaitStrategy = o.strategy
+}
+
+func (o DefaultWaitStrategyOption) ApplyDockerTo(opts *DockerProviderOptions) {
+	opts.DefaultWaitStrategy = o.strategy
+}
+
 // containerOptions functional options for a container
 type containerOptions struct {
 	ImageName           string
 	RegistryCredentials string
+	ConnectionTimeout   time.Duration
+	Retries             int
+	RetryInterval       time.Duration
+	Labels              map[string]string
+	StartupTimeout      time.Duration
+	Networks            []string
+	Logger              Logging
+	Env                 map[string]string
+	Privileged          *bool
 }
 
 // functional option for setting the reaper image
@@ -173,12 +334,215 @@ func WithRegistryCredentials(registryCredentials string) ContainerOption {
 	}
 }
 
+// WithReaperConnectTimeout sets the timeout used to connect and handshake with the reaper
+func WithReaperConnectTimeout(timeout time.Duration) ContainerOption {
+	return func(o *containerOptions) {
+		o.ConnectionTimeout = timeout
+	}
+}
+
+// WithReaperRetries sets the number of attempts to register the labels with the reaper, 3 by default
+func WithReaperRetries(retries int) ContainerOption {
+	return func(o *containerOptions) {
+		o.Retries = retries
+	}
+}
+
+// WithReaperRetryInterval sets the initial delay between two attempts to register the labels with the reaper,
+// 250 milliseconds by default. The delay doubles after each attempt
+func WithReaperRetryInterval(interval time.Duration) ContainerOption {
+	return func(o *containerOptions) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithReaperLabels sets extra labels of resources to be removed by the reaper, even the ones
+// created outside of this process. Keys prefixed with org.testcontainers.golang are reserved
+func WithReaperLabels(labels map[string]string) ContainerOption {
+	return func(o *containerOptions) {
+		o.Labels = labels
+	}
+}
+
+// WithReaperStartupTimeout sets the time allowed to start the reaper container, 60 seconds by default
+func WithReaperStartupTimeout(timeout time.Duration) ContainerOption {
+	return func(o *containerOptions) {
+		o.StartupTimeout = timeout
+	}
+}
+
+// WithReaperNetworks sets additional networks to attach the reaper container to. The networks must exist
+func WithReaperNetworks(networks ...string) ContainerOption {
+	return func(o *containerOptions) {
+		o.Networks = append(o.Networks, networks...)
+	}
+}
+
+// WithReaperLogger sets the logger used to report the failures to register the labels with the reaper.
+// Nothing is logged by default
+func WithReaperLogger(logger Logging) ContainerOption {
+	return func(o *containerOptions) {
+		o.Logger = logger
+	}
+}
+
+// WithReaperEnv sets environment variables in the reaper container, e.g. to tune RYUK_CONNECTION_TIMEOUT
+// or RYUK_RECONNECTION_TIMEOUT. RYUK_PORT cannot be set, as the reaper listening port is fixed
+func WithReaperEnv(env map[string]string) ContainerOption {
+	return func(o *containerOptions) {
+		o.Env = env
+	}
+}
+
+// WithReaperPrivileged runs the reaper container in privileged mode or not,
+// taking precedence over the ryuk.container.privileged configuration
+func WithReaperPrivileged(privileged bool) ContainerOption {
+	return func(o *containerOptions) {
+		o.Privileged = &privileged
+	}
+}
+
+// CustomizeRequestOption is a functional option customizing a ContainerRequest, applied by Customize
+type CustomizeRequestOption func(req *ContainerRequest)
+
+// Customize applies the given options to the request
+func (c *ContainerRequest) Customize(opts ...CustomizeRequestOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithImagePullRetries sets the number of retries of a pull failing with a transient registry error, after the first
+// attempt. Zero keeps the default of 2 retries, and a negative number disables them
+func WithImagePullRetries(n int) CustomizeRequestOption {
+	return func(req *ContainerRequest) {
+		req.ImagePullRetries = n
+	}
+}
+
+// WithLogDumpOnFailure sets the directory where the logs of the container are written when it fails to start,
+// or exits with a non-zero code, see ContainerRequest.LogDumpDir
+func WithLogDumpOnFailure(dir string) CustomizeRequestOption {
+	return func(req *ContainerRequest) {
+		req.LogDumpDir = dir
+	}
+}
+
+// logsOptions functional options for reading the logs of a container
+type logsOptions struct {
+	Since *time.Time
+	Until *time.Time
+	Tail  *int
+}
+
+// LogsOption is a functional option for LogsWithOptions
+type LogsOption func(*logsOptions)
+
+// WithSince returns the logs written since the given time only
+func WithSince(since time.Time) LogsOption {
+	return func(o *logsOptions) {
+		o.Since = &since
+	}
+}
+
+// WithUntil returns the logs written before the given time only
+func WithUntil(until time.Time) LogsOption {
+	return func(o *logsOptions) {
+		o.Until = &until
+	}
+}
+
+// WithTail returns the last n lines of the logs only, after the since and until filters.
+// All the lines are returned when n is negative
+func WithTail(n int) LogsOption {
+	return func(o *logsOptions) {
+		o.Tail = &n
+	}
+}
+
+// commitOptions functional options for committing a container
+type commitOptions struct {
+	Author  string
+	Message string
+	Changes []string
+}
+
+// CommitOption is a functional option for Commit
+type CommitOption func(*commitOptions)
+
+// WithCommitAuthor sets the author of the committed image, e.g. "John Doe <john@example.com>"
+func WithCommitAuthor(author string) CommitOption {
+	return func(o *commitOptions) {
+		o.Author = author
+	}
+}
+
+// WithCommitMessage sets the message of the commit, shown in the history of the image
+func WithCommitMessage(message string) CommitOption {
+	return func(o *commitOptions) {
+		o.Message = message
+	}
+}
+
+// WithCommitChanges applies Dockerfile instructions to the configuration of the committed image,
+// e.g. "ENV SEEDED=true" or "CMD [\"redis-server\"]"
+func WithCommitChanges(changes ...string) CommitOption {
+	return func(o *commitOptions) {
+		o.Changes = append(o.Changes, changes...)
+	}
+}
+
+// terminateOptions functional options for terminating a container
+type terminateOptions struct {
+	WaitForRemoval bool
+	StopTimeout    *time.Duration
+	RemoveVolumes  bool
+}
+
+// TerminateOption is a functional option for Terminate
+type TerminateOption func(*terminateOptions)
+
+// WithWaitForRemoval makes Terminate block until Docker confirms the container is removed,
+// e.g. to recreate a container with the same name right after terminating it
+func WithWaitForRemoval() TerminateOption {
+	return func(o *terminateOptions) {
+		o.WaitForRemoval = true
+	}
+}
+
+// WithStopTimeout stops the container before removing it, sending SIGTERM and waiting
+// for the given grace period before sending SIGKILL, e.g. to let a database flush its data.
+// By default, the container is killed right away
+func WithStopTimeout(timeout time.Duration) TerminateOption {
+	return func(o *terminateOptions) {
+		o.StopTimeout = &timeout
+	}
+}
+
+// WithRemoveVolumes sets whether the anonymous volumes created by the container are removed
+// along with it, which is the default
+func WithRemoveVolumes(removeVolumes bool) TerminateOption {
+	return func(o *terminateOptions) {
+		o.RemoveVolumes = removeVolumes
+	}
+}
+
 // possible provider types
 const (
 	ProviderDocker ProviderType = iota // Docker is default = 0
 	ProviderPodman
 )
 
+// PullPolicy is an enum for the possible image pull policies
+type PullPolicy int
+
+// possible pull policies
+const (
+	PullIfNotPresent PullPolicy = iota // the image is pulled when it is not present locally, default = 0
+	PullAlways                         // the image is pulled even when it is present locally, e.g. to get the latest tag
+	PullNever                          // the image is never pulled, and must be present locally, e.g. in offline CI
+)
+
 // GetProvider provides the provider implementation for a certain type
 func (t ProviderType) GetProvider(opts ...GenericProviderOption) (GenericProvider, error) {
 	opt := &GenericProviderOptions{
@@ -208,13 +572,213 @@ func (t ProviderType) GetProvider(opts ...GenericProviderOption) (GenericProvide
 	return nil, errors.New("unknown provider")
 }
 
+// WithSessionLabels adds the labels of the given session to the request, e.g. testcontainers.SessionID(),
+// so that the container is cleaned up by the reaper of that session.
+// The labels set by the user are kept, but a label reserved by Testcontainers cannot be set to another value.
+func (c *ContainerRequest) WithSessionLabels(sessionID string) error {
+	labels := sessionLabels(sessionID)
+	for k, v := range labels {
+		if current, ok := c.Labels[k]; ok && current != v {
+			return fmt.Errorf("%w: %s=%s conflicts with %s", ErrReservedLabel, k, current, v)
+		}
+	}
+
+	if c.Labels == nil {
+		c.Labels = make(map[string]string)
+	}
+	for k, v := range labels {
+		c.Labels[k] = v
+	}
+
+	return nil
+}
+
+// hash returns a hash of the parts of the request which define the container, so that a reused container
+// is created again when the request changes. The labels reserved by Testcontainers are not part of it
+func (c *ContainerRequest) hash() (string, error) {
+	labels := make(map[string]string, len(c.Labels))
+	for k, v := range c.Labels {
+		if !strings.HasPrefix(k, TestcontainerLabel) {
+			labels[k] = v
+		}
+	}
+
+	// the files are hashed by content, as their readers have no JSON representation
+	files := make([]fileHash, 0, len(c.Files))
+	for _, f := range c.Files {
+		content, err := f.contentHash()
+		if err != nil {
+			return "", err
+		}
+		files = append(files, fileHash{
+			HostFilePath:      f.HostFilePath,
+			ContainerFilePath: f.ContainerFilePath,
+			FileMode:          f.FileMode,
+			Content:           content,
+		})
+	}
+
+	// encoding/json sorts the keys of the maps, so the same request always has the same hash
+	definition, err := json.Marshal(struct {
+		Image          string
+		Context        string
+		Dockerfile     string
+		BuildArgs      map[string]*string
+		ImagePlatform  string
+		Entrypoint     []string
+		Env            map[string]string
+		ExposedPorts   []string
+		Cmd            []string
+		Labels         map[string]string
+		Mounts         ContainerMounts
+		Tmpfs          map[string]string
+		ReadOnlyRootfs bool
+		Hostname       string
+		ExtraHosts     []string
+		Privileged     bool
+		Networks       []string
+		NetworkAliases map[string][]string
+		IPv4Addresses  map[string]string
+		NetworkMode    container.NetworkMode
+		Resources      container.Resources
+		RestartPolicy  container.RestartPolicy
+		GPUs           *GPURequest
+		Files          []fileHash
+		Secrets        map[string]string
+		User           string
+		WorkingDir     string
+		StopSignal     string
+		StartupCommand []string
+		AutoRemove     bool
+		Binds          []string
+		ShmSize        int64
+		CapAdd         []string
+		CapDrop        []string
+	}{
+		Image:          c.Image,
+		Context:        c.FromDockerfile.Context,
+		Dockerfile:     c.FromDockerfile.Dockerfile,
+		BuildArgs:      c.FromDockerfile.BuildArgs,
+		ImagePlatform:  c.ImagePlatform,
+		Entrypoint:     c.Entrypoint,
+		Env:            c.Env,
+		ExposedPorts:   c.ExposedPorts,
+		Cmd:            c.Cmd,
+		Labels:         labels,
+		Mounts:         c.Mounts,
+		Tmpfs:          c.Tmpfs,
+		ReadOnlyRootfs: c.ReadOnlyRootfs,
+		Hostname:       c.Hostname,
+		ExtraHosts:     c.ExtraHosts,
+		Privileged:     c.Privileged,
+		Networks:       c.Networks,
+		NetworkAliases: c.NetworkAliases,
+		IPv4Addresses:  c.IPv4Addresses,
+		NetworkMode:    c.NetworkMode,
+		Resources:      c.Resources,
+		RestartPolicy:  c.RestartPolicy,
+		GPUs:           c.GPUs,
+		Files:          files,
+		Secrets:        c.Secrets,
+		User:           c.User,
+		WorkingDir:     c.WorkingDir,
+		StopSignal:     c.StopSignal,
+		StartupCommand: c.StartupCommand,
+		AutoRemove:     c.AutoRemove,
+		Binds:          c.Binds,
+		ShmSize:        c.ShmSize,
+		CapAdd:         c.CapAdd,
+		CapDrop:        c.CapDrop,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(definition)), nil
+}
+
+// fileHash is the part of a ContainerFile defining a reused container
+type fileHash struct {
+	HostFilePath      string
+	ContainerFilePath string
+	FileMode          int64
+	Content           string // the hash of the content of the file
+}
+
+// contentHash returns the hash of the content of the file, read from the host, or from the reader, which is rewound
+// so that the file can still be copied: a reader which cannot be rewound cannot be hashed, and so cannot be reused
+func (f ContainerFile) contentHash() (string, error) {
+	h := sha256.New()
+
+	if f.Reader != nil {
+		seeker, ok := f.Reader.(io.Seeker)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrReuseFileReader, f.ContainerFilePath)
+		}
+
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %v", ErrReuseFileReader, f.ContainerFilePath, err)
+		}
+		if _, err := io.Copy(h, f.Reader); err != nil {
+			return "", fmt.Errorf("can't read the content of %s: %w", f.ContainerFilePath, err)
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return "", fmt.Errorf("%w: %s: %v", ErrReuseFileReader, f.ContainerFilePath, err)
+		}
+
+		return fmt.Sprintf("%x", h.Sum(nil)), nil
+	}
+
+	// a directory is copied with its whole tree, so the paths and contents of its files are hashed
+	err := filepath.Walk(f.HostFilePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(f.HostFilePath, path)
+		if err != nil {
+			return err
+		}
+		_, _ = io.WriteString(h, rel+"\x00")
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(h, file)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("can't read %s: %w", f.HostFilePath, err)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
 // Validate ensures that the ContainerRequest does not have invalid parameters configured to it
 // ex. make sure you are not specifying both an image as well as a context
 func (c *ContainerRequest) Validate() error {
 	validationMethods := []func() error{
 		c.validateContextAndImage,
+		c.validateContextAndContextArchive,
 		c.validateContextOrImageIsSpecified,
 		c.validateMounts,
+		c.validateShmSize,
+		c.validateResources,
+		c.validateExtraHosts,
+		c.validateImagePlatform,
+		c.validateIPv4Addresses,
+		c.validateRestartPolicy,
+		c.validatePullPolicy,
+		c.validateVerifyDigest,
+		c.validateTmpfs,
+		c.validateGPUs,
+		c.validateSecrets,
+		c.validateFiles,
+		c.validateBuildSecrets,
 	}
 
 	var err error
@@ -270,11 +834,52 @@ func (c *ContainerRequest) ShouldPrintBuildLog() bool {
 	return c.FromDockerfile.PrintBuildLog
 }
 
+// GetBuildLogWriter returns the writer of the build output: BuildLogWriter if set,
+// otherwise os.Stderr if PrintBuildLog is set, otherwise the output is discarded
+func (c *ContainerRequest) GetBuildLogWriter() io.Writer {
+	if c.FromDockerfile.BuildLogWriter != nil {
+		return c.FromDockerfile.BuildLogWriter
+	}
+
+	if c.FromDockerfile.PrintBuildLog {
+		return os.Stderr
+	}
+
+	return io.Discard
+}
+
+// ShouldUseBuildCache returns whether the image build may reuse cached layers, which is the default
+func (c *ContainerRequest) ShouldUseBuildCache() bool {
+	return !c.FromDockerfile.NoCache
+}
+
+// ShouldPullParent returns whether the base images are pulled fresh when building the image
+func (c *ContainerRequest) ShouldPullParent() bool {
+	return c.FromDockerfile.PullParent
+}
+
+// GetBuildSecrets returns the BuildKit secrets of the image build
+func (c *ContainerRequest) GetBuildSecrets() map[string]string {
+	return c.FromDockerfile.Secrets
+}
+
 func (c *ContainerRequest) validateContextAndImage() error {
 	if c.FromDockerfile.Context != "" && c.Image != "" {
 		return errors.New("you cannot specify both an Image and Context in a ContainerRequest")
 	}
 
+	if c.FromDockerfile.ContextArchive != nil && c.Image != "" {
+		return errors.New("you cannot specify both an Image and ContextArchive in a ContainerRequest")
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateContextAndContextArchive() error {
+	if c.FromDockerfile.Context != "" && c.FromDockerfile.ContextArchive != nil {
+		return errors.New("you cannot specify both a Context and ContextArchive in a ContainerRequest")
+	}
+
 	return nil
 }
 
@@ -300,3 +905,264 @@ func (c *ContainerRequest) validateMounts() error {
 	}
 	return nil
 }
+
+func (c *ContainerRequest) validateShmSize() error {
+	// zero keeps the default size of the Docker daemon
+	if c.ShmSize < 0 {
+		return fmt.Errorf("%w: %d bytes, it cannot be negative", ErrInvalidShmSize, c.ShmSize)
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateResources() error {
+	// zero means no limit
+	if c.Resources.Memory != 0 && c.Resources.Memory < minimumMemoryLimit {
+		return fmt.Errorf("%w: %d bytes, the minimum allowed by Docker is %d bytes", ErrInvalidMemoryLimit, c.Resources.Memory, minimumMemoryLimit)
+	}
+
+	for _, ulimit := range c.Resources.Ulimits {
+		if ulimit.Name == "" {
+			return fmt.Errorf("%w: %s, the name is missing", ErrInvalidUlimit, ulimit)
+		}
+		// -1 means unlimited, so an unlimited soft limit needs an unlimited hard limit
+		if ulimit.Hard != -1 && (ulimit.Soft == -1 || ulimit.Soft > ulimit.Hard) {
+			return fmt.Errorf("%w: %s, the soft limit cannot be greater than the hard limit", ErrInvalidUlimit, ulimit)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateExtraHosts() error {
+	for _, extraHost := range c.ExtraHosts {
+		// the IP address is everything after the first colon, so that IPv6 addresses are supported
+		name, ip, found := strings.Cut(extraHost, ":")
+		if !found || name == "" {
+			return fmt.Errorf("%w: %s, it must be in the name:ip form", ErrInvalidExtraHost, extraHost)
+		}
+
+		// host-gateway is resolved by the Docker daemon to the IP address of the host
+		if ip != "host-gateway" && net.ParseIP(ip) == nil {
+			return fmt.Errorf("%w: %s, %s is neither an IP address nor host-gateway", ErrInvalidExtraHost, extraHost, ip)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateIPv4Addresses() error {
+	for networkName, address := range c.IPv4Addresses {
+		ip := net.ParseIP(address)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("%w: %s, it is not an IPv4 address", ErrInvalidIPAddress, address)
+		}
+
+		attached := false
+		for _, n := range c.Networks {
+			if n == networkName {
+				attached = true
+				break
+			}
+		}
+		if !attached {
+			return fmt.Errorf("%w: %s, the container is not attached to network %s", ErrInvalidIPAddress, address, networkName)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateRestartPolicy() error {
+	switch c.RestartPolicy.Name {
+	case "", "no", "always", "unless-stopped":
+		if c.RestartPolicy.MaximumRetryCount != 0 {
+			return fmt.Errorf("%w: the maximum retry count is only allowed with on-failure, not %q", ErrInvalidRestartPolicy, c.RestartPolicy.Name)
+		}
+	case "on-failure":
+		if c.RestartPolicy.MaximumRetryCount < 0 {
+			return fmt.Errorf("%w: %d retries, the maximum retry count cannot be negative", ErrInvalidRestartPolicy, c.RestartPolicy.MaximumRetryCount)
+		}
+	default:
+		return fmt.Errorf("%w: %s, it must be one of no, on-failure, always or unless-stopped", ErrInvalidRestartPolicy, c.RestartPolicy.Name)
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateGPUs() error {
+	if c.GPUs == nil {
+		return nil
+	}
+
+	switch {
+	case c.GPUs.Count < -1:
+		return fmt.Errorf("%w: %d GPUs, the count must be positive, or -1 for all the GPUs", ErrInvalidGPURequest, c.GPUs.Count)
+	case c.GPUs.Count != 0 && len(c.GPUs.DeviceIDs) > 0:
+		return fmt.Errorf("%w: the count and the device IDs cannot be set together", ErrInvalidGPURequest)
+	case c.GPUs.Count == 0 && len(c.GPUs.DeviceIDs) == 0:
+		return fmt.Errorf("%w: either the count or the device IDs must be set", ErrInvalidGPURequest)
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateFiles() error {
+	for _, f := range c.Files {
+		if (f.HostFilePath == "") == (f.Reader == nil) {
+			return fmt.Errorf("%w: %s, either the host file path or the reader must be set", ErrInvalidFile, f.ContainerFilePath)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateBuildSecrets() error {
+	if len(c.FromDockerfile.Secrets) == 0 {
+		return nil
+	}
+
+	if !c.ShouldBuildImage() {
+		return fmt.Errorf("%w: the secrets are only used to build an image from a Dockerfile", ErrInvalidBuildSecret)
+	}
+
+	for id := range c.FromDockerfile.Secrets {
+		if id == "" {
+			return fmt.Errorf("%w: the ID of a secret cannot be empty", ErrInvalidBuildSecret)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateSecrets() error {
+	for name := range c.Secrets {
+		// the secrets are files of the /run/secrets directory
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			return fmt.Errorf("%w: %q, the name must be a file name", ErrInvalidSecret, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validatePullPolicy() error {
+	switch c.PullPolicy {
+	case PullIfNotPresent, PullAlways:
+	case PullNever:
+		if c.AlwaysPullImage {
+			return fmt.Errorf("%w: AlwaysPullImage cannot be set with PullNever", ErrInvalidPullPolicy)
+		}
+	default:
+		return fmt.Errorf("%w: %d", ErrInvalidPullPolicy, c.PullPolicy)
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateVerifyDigest() error {
+	if !c.VerifyDigest {
+		return nil
+	}
+
+	if c.ShouldBuildImage() {
+		return fmt.Errorf("%w: the digest of a built image cannot be verified", ErrInvalidImageDigest)
+	}
+
+	if _, err := imageDigest(c.Image); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// imageDigest returns the repository and the digest pinned in the image reference, e.g. docker.io/library/alpine
+// and sha256:... for alpine@sha256:...
+func imageDigest(image string) (reference.Canonical, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s, %v", ErrInvalidImageDigest, image, err)
+	}
+
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, the image must be pinned by digest, in the name@sha256:... form", ErrInvalidImageDigest, image)
+	}
+
+	return canonical, nil
+}
+
+// tmpfsFlags are the options without value of a tmpfs mount
+var tmpfsFlags = map[string]bool{
+	"rw": true, "ro": true, "exec": true, "noexec": true, "suid": true, "nosuid": true, "dev": true, "nodev": true,
+	"sync": true, "async": true, "dirsync": true, "atime": true, "noatime": true, "diratime": true, "nodiratime": true,
+	"relatime": true, "norelatime": true, "strictatime": true, "nostrictatime": true,
+}
+
+// tmpfsSizeRegexp matches a size in bytes, with an optional k, m or g suffix, or in percent of the memory
+var tmpfsSizeRegexp = regexp.MustCompile(`^[0-9]+[kKmMgG%]?$`)
+
+func (c *ContainerRequest) validateTmpfs() error {
+	for target, options := range c.Tmpfs {
+		if !strings.HasPrefix(target, "/") {
+			return fmt.Errorf("%w: %s, the target must be an absolute path", ErrInvalidTmpfs, target)
+		}
+
+		// empty means the default options of Docker
+		if options == "" {
+			continue
+		}
+
+		for _, option := range strings.Split(options, ",") {
+			key, value, hasValue := strings.Cut(option, "=")
+			if !hasValue {
+				if !tmpfsFlags[key] {
+					return fmt.Errorf("%w: %s, unknown option %s", ErrInvalidTmpfs, target, option)
+				}
+				continue
+			}
+
+			valid := false
+			switch key {
+			case "size", "nr_inodes":
+				valid = tmpfsSizeRegexp.MatchString(value)
+			case "mode":
+				mode, err := strconv.ParseUint(value, 8, 32)
+				valid = err == nil && mode <= 07777
+			case "uid", "gid":
+				_, err := strconv.ParseUint(value, 10, 32)
+				valid = err == nil
+			default:
+				return fmt.Errorf("%w: %s, unknown option %s", ErrInvalidTmpfs, target, key)
+			}
+			if !valid {
+				return fmt.Errorf("%w: %s, invalid %s %s", ErrInvalidTmpfs, target, key, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerRequest) validateImagePlatform() error {
+	// empty means the platform of the Docker daemon
+	if c.ImagePlatform == "" {
+		return nil
+	}
+
+	parts := strings.Split(c.ImagePlatform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("%w: %s, it must be in the os/arch[/variant] form", ErrInvalidImagePlatform, c.ImagePlatform)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("%w: %s, it must be in the os/arch[/variant] form", ErrInvalidImagePlatform, c.ImagePlatform)
+		}
+	}
+
+	if _, err := platforms.Parse(c.ImagePlatform); err != nil {
+		return fmt.Errorf("%w: %s, %v", ErrInvalidImagePlatform, c.ImagePlatform, err)
+	}
+
+	return nil
+}
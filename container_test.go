@@ -2,17 +2,29 @@ package testcontainers
 
 import (
 	"archive/tar"
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -36,6 +48,35 @@ func Test_ContainerValidation(t *testing.T) {
 				Image: "redis:latest",
 			},
 		},
+		{
+			Name:          "cannot set both context archive and image",
+			ExpectedError: errors.New("you cannot specify both an Image and ContextArchive in a ContainerRequest"),
+			ContainerRequest: ContainerRequest{
+				FromDockerfile: FromDockerfile{
+					ContextArchive: &bytes.Buffer{},
+				},
+				Image: "redis:latest",
+			},
+		},
+		{
+			Name:          "cannot set both context and context archive",
+			ExpectedError: errors.New("you cannot specify both a Context and ContextArchive in a ContainerRequest"),
+			ContainerRequest: ContainerRequest{
+				FromDockerfile: FromDockerfile{
+					Context:        ".",
+					ContextArchive: &bytes.Buffer{},
+				},
+			},
+		},
+		{
+			Name:          "must set either a context, a context archive or an image",
+			ExpectedError: errors.New("you must specify either a build context or an image"),
+			ContainerRequest: ContainerRequest{
+				FromDockerfile: FromDockerfile{
+					Dockerfile: "Dockerfile",
+				},
+			},
+		},
 		{
 			Name:          "can set image without context",
 			ExpectedError: nil,
@@ -68,6 +109,356 @@ func Test_ContainerValidation(t *testing.T) {
 				Mounts: Mounts(BindMount("/srv", "/data"), BindMount("/data", "/data")),
 			},
 		},
+		{
+			Name:          "can set the shm size",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:   "redis:latest",
+				ShmSize: 1024 * 1024 * 1024,
+			},
+		},
+		{
+			Name:          "cannot set a negative shm size",
+			ExpectedError: errors.New("invalid shm size: -1 bytes, it cannot be negative"),
+			ContainerRequest: ContainerRequest{
+				Image:   "redis:latest",
+				ShmSize: -1,
+			},
+		},
+		{
+			Name:          "can set resource limits",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Resources: container.Resources{
+					Memory:   64 * 1024 * 1024,
+					NanoCPUs: 500000000,
+				},
+			},
+		},
+		{
+			Name:          "cannot set a memory limit below the Docker minimum",
+			ExpectedError: errors.New("invalid memory limit: 1048576 bytes, the minimum allowed by Docker is 6291456 bytes"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Resources: container.Resources{
+					Memory: 1024 * 1024,
+				},
+			},
+		},
+		{
+			Name:          "can set unlimited and raised ulimits",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Resources: container.Resources{
+					Ulimits: []*units.Ulimit{
+						{Name: "memlock", Soft: -1, Hard: -1},
+						{Name: "nofile", Soft: 65536, Hard: 65536},
+						{Name: "nproc", Soft: 4096, Hard: -1},
+					},
+				},
+			},
+		},
+		{
+			Name:          "cannot set a soft ulimit greater than the hard one",
+			ExpectedError: errors.New("invalid ulimit: nofile=65536:1024, the soft limit cannot be greater than the hard limit"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Resources: container.Resources{
+					Ulimits: []*units.Ulimit{{Name: "nofile", Soft: 65536, Hard: 1024}},
+				},
+			},
+		},
+		{
+			Name:          "cannot set an unlimited soft ulimit with a hard limit",
+			ExpectedError: errors.New("invalid ulimit: nofile=-1:1024, the soft limit cannot be greater than the hard limit"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Resources: container.Resources{
+					Ulimits: []*units.Ulimit{{Name: "nofile", Soft: -1, Hard: 1024}},
+				},
+			},
+		},
+		{
+			Name:          "can set extra hosts",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:      "redis:latest",
+				ExtraHosts: []string{"host.docker.internal:host-gateway", "mock.server:10.0.0.1", "ipv6.server:::1"},
+			},
+		},
+		{
+			Name:          "cannot set an extra host without IP address",
+			ExpectedError: errors.New("invalid extra host: mock.server, it must be in the name:ip form"),
+			ContainerRequest: ContainerRequest{
+				Image:      "redis:latest",
+				ExtraHosts: []string{"mock.server"},
+			},
+		},
+		{
+			Name:          "cannot set an extra host with an invalid IP address",
+			ExpectedError: errors.New("invalid extra host: mock.server:localhost, localhost is neither an IP address nor host-gateway"),
+			ContainerRequest: ContainerRequest{
+				Image:      "redis:latest",
+				ExtraHosts: []string{"mock.server:localhost"},
+			},
+		},
+		{
+			Name:          "can set a static IPv4 address on a network of the container",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				Networks:      []string{"my-network"},
+				IPv4Addresses: map[string]string{"my-network": "10.1.2.3"},
+			},
+		},
+		{
+			Name:          "cannot set a static IPv6 address",
+			ExpectedError: errors.New("invalid IP address: ::1, it is not an IPv4 address"),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				Networks:      []string{"my-network"},
+				IPv4Addresses: map[string]string{"my-network": "::1"},
+			},
+		},
+		{
+			Name:          "cannot set a static IPv4 address on another network",
+			ExpectedError: errors.New("invalid IP address: 10.1.2.3, the container is not attached to network other-network"),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				Networks:      []string{"my-network"},
+				IPv4Addresses: map[string]string{"other-network": "10.1.2.3"},
+			},
+		},
+		{
+			Name:          "can set an on-failure restart policy with a maximum retry count",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				RestartPolicy: container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+			},
+		},
+		{
+			Name:          "cannot set an unknown restart policy",
+			ExpectedError: errors.New("invalid restart policy: sometimes, it must be one of no, on-failure, always or unless-stopped"),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				RestartPolicy: container.RestartPolicy{Name: "sometimes"},
+			},
+		},
+		{
+			Name:          "cannot set a maximum retry count with another restart policy than on-failure",
+			ExpectedError: errors.New("invalid restart policy: the maximum retry count is only allowed with on-failure, not \"always\""),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				RestartPolicy: container.RestartPolicy{Name: "always", MaximumRetryCount: 3},
+			},
+		},
+		{
+			Name:          "cannot set an unknown pull policy",
+			ExpectedError: errors.New("invalid pull policy: 42"),
+			ContainerRequest: ContainerRequest{
+				Image:      "redis:latest",
+				PullPolicy: PullPolicy(42),
+			},
+		},
+		{
+			Name:          "cannot always pull an image which is never pulled",
+			ExpectedError: errors.New("invalid pull policy: AlwaysPullImage cannot be set with PullNever"),
+			ContainerRequest: ContainerRequest{
+				Image:           "redis:latest",
+				AlwaysPullImage: true,
+				PullPolicy:      PullNever,
+			},
+		},
+		{
+			Name:          "can verify the digest of a pinned image",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:        "redis@sha256:" + strings.Repeat("a", 64),
+				VerifyDigest: true,
+			},
+		},
+		{
+			Name:          "cannot verify the digest of an image pinned by tag",
+			ExpectedError: errors.New("invalid image digest: redis:latest, the image must be pinned by digest, in the name@sha256:... form"),
+			ContainerRequest: ContainerRequest{
+				Image:        "redis:latest",
+				VerifyDigest: true,
+			},
+		},
+		{
+			Name:          "can request all the GPUs",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				GPUs:  &GPURequest{Count: -1},
+			},
+		},
+		{
+			Name:          "cannot request a negative number of GPUs",
+			ExpectedError: errors.New("invalid GPU request: -2 GPUs, the count must be positive, or -1 for all the GPUs"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				GPUs:  &GPURequest{Count: -2},
+			},
+		},
+		{
+			Name:          "cannot request GPUs by count and by device IDs",
+			ExpectedError: errors.New("invalid GPU request: the count and the device IDs cannot be set together"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				GPUs:  &GPURequest{Count: 1, DeviceIDs: []string{"0"}},
+			},
+		},
+		{
+			Name:          "cannot request no GPU",
+			ExpectedError: errors.New("invalid GPU request: either the count or the device IDs must be set"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				GPUs:  &GPURequest{Capabilities: []string{"compute"}},
+			},
+		},
+		{
+			Name:          "can set files from the host and from a reader",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Files: []ContainerFile{
+					{HostFilePath: "./testresources/hello.sh", ContainerFilePath: "/hello.sh", FileMode: 0o755},
+					{Reader: strings.NewReader("port 6380"), ContainerFilePath: "/redis.conf", FileMode: 0o644},
+				},
+			},
+		},
+		{
+			Name:          "cannot set a file from the host and from a reader",
+			ExpectedError: errors.New("invalid file: /redis.conf, either the host file path or the reader must be set"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Files: []ContainerFile{
+					{HostFilePath: "./redis.conf", Reader: strings.NewReader("port 6380"), ContainerFilePath: "/redis.conf"},
+				},
+			},
+		},
+		{
+			Name:          "cannot set a file without content",
+			ExpectedError: errors.New("invalid file: /redis.conf, either the host file path or the reader must be set"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Files: []ContainerFile{{ContainerFilePath: "/redis.conf"}},
+			},
+		},
+		{
+			Name:          "can set secrets",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:   "redis:latest",
+				Secrets: map[string]string{"db_password": "s3cr3t"},
+			},
+		},
+		{
+			Name:          "cannot set a secret outside of the secrets directory",
+			ExpectedError: errors.New("invalid secret: \"../passwd\", the name must be a file name"),
+			ContainerRequest: ContainerRequest{
+				Image:   "redis:latest",
+				Secrets: map[string]string{"../passwd": "root"},
+			},
+		},
+		{
+			Name:          "can set build secrets",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				FromDockerfile: FromDockerfile{
+					Context: ".",
+					Secrets: map[string]string{"token": "s3cr3t"},
+				},
+			},
+		},
+		{
+			Name:          "cannot set build secrets without building an image",
+			ExpectedError: errors.New("invalid build secret: the secrets are only used to build an image from a Dockerfile"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				FromDockerfile: FromDockerfile{
+					Secrets: map[string]string{"token": "s3cr3t"},
+				},
+			},
+		},
+		{
+			Name:          "cannot set a build secret without ID",
+			ExpectedError: errors.New("invalid build secret: the ID of a secret cannot be empty"),
+			ContainerRequest: ContainerRequest{
+				FromDockerfile: FromDockerfile{
+					Context: ".",
+					Secrets: map[string]string{"": "s3cr3t"},
+				},
+			},
+		},
+		{
+			Name:          "can set a sized tmpfs mount",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Tmpfs: map[string]string{"/data": "rw,size=64m,mode=1777,uid=999", "/cache": ""},
+			},
+		},
+		{
+			Name:          "cannot set a tmpfs mount on a relative path",
+			ExpectedError: errors.New("invalid tmpfs mount: data, the target must be an absolute path"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Tmpfs: map[string]string{"data": "rw"},
+			},
+		},
+		{
+			Name:          "cannot set a tmpfs mount with an invalid size",
+			ExpectedError: errors.New("invalid tmpfs mount: /data, invalid size 64mb"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Tmpfs: map[string]string{"/data": "rw,size=64mb"},
+			},
+		},
+		{
+			Name:          "cannot set a tmpfs mount with a non octal mode",
+			ExpectedError: errors.New("invalid tmpfs mount: /data, invalid mode 1999"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Tmpfs: map[string]string{"/data": "mode=1999"},
+			},
+		},
+		{
+			Name:          "cannot set a tmpfs mount with an unknown option",
+			ExpectedError: errors.New("invalid tmpfs mount: /data, unknown option fast"),
+			ContainerRequest: ContainerRequest{
+				Image: "redis:latest",
+				Tmpfs: map[string]string{"/data": "rw,fast"},
+			},
+		},
+		{
+			Name:          "can set an image platform with a variant",
+			ExpectedError: nil,
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				ImagePlatform: "linux/arm64/v8",
+			},
+		},
+		{
+			Name:          "cannot set an image platform without architecture",
+			ExpectedError: errors.New("invalid image platform: linux, it must be in the os/arch[/variant] form"),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				ImagePlatform: "linux",
+			},
+		},
+		{
+			Name:          "cannot set an image platform with an empty part",
+			ExpectedError: errors.New("invalid image platform: linux//v7, it must be in the os/arch[/variant] form"),
+			ContainerRequest: ContainerRequest{
+				Image:         "redis:latest",
+				ImagePlatform: "linux//v7",
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -127,6 +518,38 @@ func Test_GetDockerfile(t *testing.T) {
 	}
 }
 
+func Test_GetBuildLogWriter(t *testing.T) {
+	buildLog := &bytes.Buffer{}
+
+	testTable := []struct {
+		name           string
+		fromDockerfile FromDockerfile
+		expected       io.Writer
+	}{
+		{
+			name:     "output is discarded by default",
+			expected: io.Discard,
+		},
+		{
+			name:           "output is printed to stderr",
+			fromDockerfile: FromDockerfile{PrintBuildLog: true},
+			expected:       os.Stderr,
+		},
+		{
+			name:           "output is written to the writer",
+			fromDockerfile: FromDockerfile{PrintBuildLog: true, BuildLogWriter: buildLog},
+			expected:       buildLog,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := ContainerRequest{FromDockerfile: testCase.fromDockerfile}
+			assert.Equal(t, testCase.expected, req.GetBuildLogWriter())
+		})
+	}
+}
+
 func Test_GetAuthConfigs(t *testing.T) {
 	type TestCase struct {
 		name                string
@@ -464,3 +887,725 @@ func TestVolumeMount(t *testing.T) {
 		})
 	}
 }
+
+func Test_WithImagePullRetries(t *testing.T) {
+	req := ContainerRequest{Image: "docker.io/alpine"}
+	req.Customize(WithImagePullRetries(5))
+
+	assert.Equal(t, 5, req.ImagePullRetries)
+}
+
+func Test_WithLogDumpOnFailure(t *testing.T) {
+	req := ContainerRequest{Image: "docker.io/alpine"}
+	req.Customize(WithLogDumpOnFailure("build/container-logs"))
+
+	assert.Equal(t, "build/container-logs", req.LogDumpDir)
+}
+
+func Test_ContainerRequestWithSessionLabels(t *testing.T) {
+	t.Run("adds the session labels", func(t *testing.T) {
+		req := ContainerRequest{
+			Image:  "redis:latest",
+			Labels: map[string]string{"app": "redis"},
+		}
+
+		require.NoError(t, req.WithSessionLabels("session-1"))
+		assert.Equal(t, map[string]string{
+			"app":                       "redis",
+			TestcontainerLabel:          "true",
+			TestcontainerLabelSessionID: "session-1",
+		}, req.Labels)
+	})
+
+	t.Run("initialises the labels", func(t *testing.T) {
+		req := ContainerRequest{Image: "redis:latest"}
+
+		require.NoError(t, req.WithSessionLabels(SessionID()))
+		assert.Equal(t, SessionID(), req.Labels[TestcontainerLabelSessionID])
+	})
+
+	t.Run("fails when a reserved label is set to another value", func(t *testing.T) {
+		req := ContainerRequest{
+			Image:  "redis:latest",
+			Labels: map[string]string{TestcontainerLabelSessionID: "session-2"},
+		}
+
+		err := req.WithSessionLabels("session-1")
+		assert.ErrorIs(t, err, ErrReservedLabel)
+		assert.Equal(t, map[string]string{TestcontainerLabelSessionID: "session-2"}, req.Labels)
+	})
+}
+
+// terminateRecorderClient records the calls made to Docker when terminating a container
+type terminateRecorderClient struct {
+	client.APIClient
+	stopOptions   *container.StopOptions
+	removeOptions *types.ContainerRemoveOptions
+}
+
+func (c *terminateRecorderClient) ContainerStop(_ context.Context, _ string, options container.StopOptions) error {
+	c.stopOptions = &options
+	return nil
+}
+
+func (c *terminateRecorderClient) ContainerRemove(_ context.Context, _ string, options types.ContainerRemoveOptions) error {
+	c.removeOptions = &options
+	return nil
+}
+
+func (c *terminateRecorderClient) Close() error {
+	return nil
+}
+
+func Test_TerminateOptions(t *testing.T) {
+	tests := []struct {
+		name                  string
+		options               []TerminateOption
+		expectedStopTimeout   *int
+		expectedRemoveVolumes bool
+	}{
+		{
+			name:                  "defaults kill the container and remove its volumes",
+			expectedRemoveVolumes: true,
+		},
+		{
+			name:                  "stop timeout is passed to Docker",
+			options:               []TerminateOption{WithStopTimeout(15 * time.Second)},
+			expectedStopTimeout:   func(i int) *int { return &i }(15),
+			expectedRemoveVolumes: true,
+		},
+		{
+			name:                  "volumes are kept",
+			options:               []TerminateOption{WithRemoveVolumes(false)},
+			expectedRemoveVolumes: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &terminateRecorderClient{}
+			c := &DockerContainer{
+				ID:       "0123456789abcdef",
+				provider: &DockerProvider{client: recorder},
+				logger:   Logger,
+			}
+
+			require.NoError(t, c.Terminate(context.Background(), tt.options...))
+
+			if tt.expectedStopTimeout == nil {
+				assert.Nil(t, recorder.stopOptions, "the container should not be stopped")
+			} else {
+				require.NotNil(t, recorder.stopOptions)
+				assert.Equal(t, tt.expectedStopTimeout, recorder.stopOptions.Timeout)
+			}
+
+			require.NotNil(t, recorder.removeOptions)
+			assert.True(t, recorder.removeOptions.Force)
+			assert.Equal(t, tt.expectedRemoveVolumes, recorder.removeOptions.RemoveVolumes)
+			assert.False(t, c.IsRunning())
+		})
+	}
+}
+
+func Test_TerminateWithStopSignal(t *testing.T) {
+	recorder := &terminateRecorderClient{}
+	c := &DockerContainer{
+		ID:         "0123456789abcdef",
+		provider:   &DockerProvider{client: recorder},
+		logger:     Logger,
+		stopSignal: "SIGINT",
+	}
+
+	require.NoError(t, c.Terminate(context.Background()))
+
+	require.NotNil(t, recorder.stopOptions, "the container should be stopped with its stop signal")
+	assert.Nil(t, recorder.stopOptions.Timeout, "the stop timeout of the container should be used")
+	require.NotNil(t, recorder.removeOptions)
+}
+
+// removedContainerClient reports the containers as already removed
+type removedContainerClient struct {
+	terminateRecorderClient
+	removals int
+}
+
+func (c *removedContainerClient) ContainerRemove(_ context.Context, id string, _ types.ContainerRemoveOptions) error {
+	c.removals++
+	return errdefs.NotFound(fmt.Errorf("No such container: %s", id))
+}
+
+func Test_TerminateTwice(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("terminated container", func(t *testing.T) {
+		var hooks int
+		recorder := &terminateRecorderClient{}
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: recorder},
+			logger:   Logger,
+			lifecycleHooks: ContainerLifecycleHooks{
+				PreTerminates: []ContainerHook{func(_ context.Context, _ Container) error {
+					hooks++
+					return nil
+				}},
+			},
+		}
+
+		require.NoError(t, c.Terminate(ctx))
+		require.NotNil(t, recorder.removeOptions)
+
+		recorder.removeOptions = nil
+		require.NoError(t, c.Terminate(ctx))
+		assert.Nil(t, recorder.removeOptions, "a terminated container should not be removed again")
+		assert.Equal(t, 1, hooks, "the hooks should be executed once")
+	})
+
+	t.Run("removed container", func(t *testing.T) {
+		recorder := &removedContainerClient{}
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: recorder},
+			logger:   Logger,
+		}
+
+		require.NoError(t, c.Terminate(ctx))
+		require.NoError(t, c.Terminate(ctx))
+		assert.Equal(t, 1, recorder.removals)
+	})
+}
+
+// lifecycleRecorderClient starts the containers, and records their termination
+type lifecycleRecorderClient struct {
+	terminateRecorderClient
+}
+
+func (c *lifecycleRecorderClient) ContainerStart(_ context.Context, _ string, _ types.ContainerStartOptions) error {
+	return nil
+}
+
+func Test_LifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hooks are executed in order", func(t *testing.T) {
+		var calls []string
+		hook := func(name string) ContainerHook {
+			return func(_ context.Context, _ Container) error {
+				calls = append(calls, name)
+				return nil
+			}
+		}
+
+		recorder := &lifecycleRecorderClient{}
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: recorder},
+			logger:   Logger,
+			lifecycleHooks: ContainerLifecycleHooks{
+				PostStarts:    []ContainerHook{hook("post start 1"), hook("post start 2")},
+				PreTerminates: []ContainerHook{hook("pre terminate")},
+			},
+		}
+
+		require.NoError(t, c.Start(ctx))
+		assert.Equal(t, []string{"post start 1", "post start 2"}, calls)
+		assert.Nil(t, recorder.removeOptions)
+
+		require.NoError(t, c.Terminate(ctx))
+		assert.Equal(t, []string{"post start 1", "post start 2", "pre terminate"}, calls)
+		assert.NotNil(t, recorder.removeOptions)
+	})
+
+	t.Run("errors abort the start and the termination", func(t *testing.T) {
+		errHook := errors.New("schema creation failed")
+		failing := func(_ context.Context, _ Container) error {
+			return errHook
+		}
+
+		recorder := &lifecycleRecorderClient{}
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: recorder},
+			logger:   Logger,
+			lifecycleHooks: ContainerLifecycleHooks{
+				PostStarts:    []ContainerHook{failing},
+				PreTerminates: []ContainerHook{failing},
+			},
+		}
+
+		require.ErrorIs(t, c.Start(ctx), errHook)
+		require.ErrorIs(t, c.Terminate(ctx), errHook)
+		assert.Nil(t, recorder.removeOptions, "the container should not be removed")
+	})
+}
+
+// startupCommandClient starts the containers, and executes the commands with the given output and exit code
+type startupCommandClient struct {
+	lifecycleRecorderClient
+	output   string
+	exitCode int
+	cmds     [][]string
+}
+
+func (c *startupCommandClient) ContainerExecCreate(_ context.Context, _ string, config types.ExecConfig) (types.IDResponse, error) {
+	c.cmds = append(c.cmds, config.Cmd)
+	return types.IDResponse{ID: "exec"}, nil
+}
+
+func (c *startupCommandClient) ContainerExecAttach(_ context.Context, _ string, _ types.ExecStartCheck) (types.HijackedResponse, error) {
+	var buf bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(c.output)); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	conn, _ := net.Pipe()
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&buf)}, nil
+}
+
+func (c *startupCommandClient) ContainerExecInspect(_ context.Context, _ string) (types.ContainerExecInspect, error) {
+	return types.ContainerExecInspect{ExitCode: c.exitCode}, nil
+}
+
+func Test_StartupCommand(t *testing.T) {
+	ctx := context.Background()
+	cmd := []string{"initdb", "-D", "/var/lib/data"}
+
+	t.Run("executed before the wait strategy", func(t *testing.T) {
+		cli := &startupCommandClient{}
+		var waited bool
+		c := &DockerContainer{
+			ID: "0123456789abcdef",
+			WaitingFor: wait.ForNop(func(_ context.Context, _ wait.StrategyTarget) error {
+				waited = true
+				assert.Equal(t, [][]string{cmd}, cli.cmds, "the startup command should be executed before the wait")
+				return nil
+			}),
+			provider:       &DockerProvider{client: cli},
+			logger:         TestLogger(t),
+			startupCommand: cmd,
+		}
+
+		require.NoError(t, c.Start(ctx))
+		assert.True(t, waited)
+		assert.True(t, c.IsRunning())
+	})
+
+	t.Run("failure aborts the start", func(t *testing.T) {
+		cli := &startupCommandClient{output: "initdb: directory exists but is not empty\n", exitCode: 1}
+		var waited bool
+		c := &DockerContainer{
+			ID: "0123456789abcdef",
+			WaitingFor: wait.ForNop(func(_ context.Context, _ wait.StrategyTarget) error {
+				waited = true
+				return nil
+			}),
+			provider:       &DockerProvider{client: cli},
+			logger:         TestLogger(t),
+			startupCommand: cmd,
+		}
+
+		err := c.Start(ctx)
+		require.ErrorIs(t, err, ErrStartupCommandFailed)
+		assert.Contains(t, err.Error(), "exited with code 1")
+		assert.Contains(t, err.Error(), "directory exists but is not empty")
+		assert.False(t, waited, "the wait strategy should not begin")
+		assert.False(t, c.IsRunning())
+	})
+}
+
+// repoDigestsClient inspects the images with the given repository digests
+type repoDigestsClient struct {
+	client.APIClient
+	repoDigests []string
+}
+
+func (c *repoDigestsClient) ImageInspectWithRaw(_ context.Context, _ string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{ID: "sha256:0123456789abcdef", RepoDigests: c.repoDigests}, nil, nil
+}
+
+func Test_VerifyImageDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	otherDigest := "sha256:" + strings.Repeat("b", 64)
+
+	tests := []struct {
+		name        string
+		image       string
+		repoDigests []string
+		expectedErr error
+	}{
+		{
+			name:        "pinned digest",
+			image:       "redis@" + digest,
+			repoDigests: []string{"redis@" + otherDigest, "redis@" + digest},
+		},
+		{
+			name:        "normalized repository",
+			image:       "docker.io/library/redis@" + digest,
+			repoDigests: []string{"redis@" + digest},
+		},
+		{
+			name:        "other digest",
+			image:       "redis@" + digest,
+			repoDigests: []string{"redis@" + otherDigest},
+			expectedErr: ErrImageDigestMismatch,
+		},
+		{
+			name:        "other repository",
+			image:       "redis@" + digest,
+			repoDigests: []string{"registry.example.com/redis@" + digest},
+			expectedErr: ErrImageDigestMismatch,
+		},
+		{
+			name:        "image without repository digest",
+			image:       "redis@" + digest,
+			expectedErr: ErrImageDigestMismatch,
+		},
+		{
+			name:        "image pinned by tag",
+			image:       "redis:7",
+			repoDigests: []string{"redis@" + digest},
+			expectedErr: ErrInvalidImageDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &DockerProvider{client: &repoDigestsClient{repoDigests: tt.repoDigests}}
+
+			err := p.verifyImageDigest(context.Background(), tt.image)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+// failingStrategy is a wait strategy which always fails
+type failingStrategy struct{}
+
+func (failingStrategy) WaitUntilReady(_ context.Context, _ wait.StrategyTarget) error {
+	return errors.New("the container is not ready")
+}
+
+// logsClient starts the containers, which are in the given state, and returns the given logs
+type logsClient struct {
+	client.APIClient
+	logs  string
+	state types.ContainerState
+}
+
+func (c *logsClient) ContainerStart(_ context.Context, _ string, _ types.ContainerStartOptions) error {
+	return nil
+}
+
+func (c *logsClient) ContainerInspect(_ context.Context, id string) (types.ContainerJSON, error) {
+	state := c.state
+	return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: id, State: &state}}, nil
+}
+
+func (c *logsClient) ContainerLogs(_ context.Context, _ string, _ types.ContainerLogsOptions) (io.ReadCloser, error) {
+	// Docker writes a frame per line
+	var buf bytes.Buffer
+	w := stdcopy.NewStdWriter(&buf, stdcopy.Stdout)
+	for _, line := range strings.SplitAfter(c.logs, "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := w.Write([]byte(line)); err != nil {
+			return nil, err
+		}
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func Test_LogDumpOnFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	sessionID := uuid.New()
+
+	c := &DockerContainer{
+		ID:         "0123456789abcdef",
+		WaitingFor: failingStrategy{},
+		provider:   &DockerProvider{client: &logsClient{logs: "connection refused\n"}},
+		logger:     TestLogger(t),
+		sessionID:  sessionID,
+		logDumpDir: dir,
+	}
+
+	require.Error(t, c.Start(context.Background()))
+
+	content, err := os.ReadFile(filepath.Join(dir, "0123456789ab-"+sessionID.String()+".log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "connection refused\n")
+}
+
+func Test_ContainerStartError(t *testing.T) {
+	start := func(state types.ContainerState) error {
+		c := &DockerContainer{
+			ID:         "0123456789abcdef",
+			WaitingFor: failingStrategy{},
+			provider:   &DockerProvider{client: &logsClient{logs: "first line\nconfiguration error\n", state: state}},
+			logger:     TestLogger(t),
+		}
+		return c.Start(context.Background())
+	}
+
+	t.Run("exited container", func(t *testing.T) {
+		err := start(types.ContainerState{Status: "exited", ExitCode: 3})
+
+		var startErr *ContainerStartError
+		require.ErrorAs(t, err, &startErr)
+		assert.Equal(t, "0123456789abcdef", startErr.ID)
+		assert.Equal(t, 3, startErr.ExitCode)
+		assert.Contains(t, startErr.Logs, "configuration error")
+		assert.ErrorContains(t, err, "container 0123456789ab exited with code 3 before being ready: the container is not ready")
+		assert.ErrorContains(t, err, "configuration error")
+	})
+
+	t.Run("running container", func(t *testing.T) {
+		err := start(types.ContainerState{Status: "running", Running: true})
+
+		var startErr *ContainerStartError
+		assert.False(t, errors.As(err, &startErr))
+		assert.EqualError(t, err, "the container is not ready")
+	})
+
+	t.Run("short ID", func(t *testing.T) {
+		for _, id := range []string{"", "abc"} {
+			err := &ContainerStartError{ID: id, ExitCode: 1, Err: errors.New("the container is not ready")}
+			assert.NotPanics(t, func() { _ = err.Error() })
+			assert.Contains(t, err.Error(), "container "+id+" exited with code 1")
+		}
+	})
+}
+
+// inspectRecorderClient counts the inspects of a container, and starts it
+type inspectRecorderClient struct {
+	client.APIClient
+	inspects int
+}
+
+func (c *inspectRecorderClient) ContainerInspectWithRaw(_ context.Context, id string, _ bool) (types.ContainerJSON, []byte, error) {
+	c.inspects++
+	raw := []byte(fmt.Sprintf(`{"Id":%q,"State":{"Status":"running","Running":true}}`, id))
+	var inspect types.ContainerJSON
+	err := json.Unmarshal(raw, &inspect)
+	return inspect, raw, err
+}
+
+func (c *inspectRecorderClient) ContainerStart(_ context.Context, _ string, _ types.ContainerStartOptions) error {
+	return nil
+}
+
+func Test_InspectCache(t *testing.T) {
+	ctx := context.Background()
+	recorder := &inspectRecorderClient{}
+	c := &DockerContainer{
+		ID:       "0123456789abcdef",
+		provider: &DockerProvider{client: recorder},
+		logger:   Logger,
+	}
+
+	first, err := c.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", first.ID)
+
+	// the callers get a copy, which they can modify without changing the cache
+	first.State.Running = false
+
+	second, err := c.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recorder.inspects, "two rapid inspects should hit the cache")
+	assert.True(t, second.State.Running)
+
+	require.NoError(t, c.Start(ctx))
+	_, err = c.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, recorder.inspects, "the cache should be invalidated by the start")
+
+	c.inspectedAt = time.Now().Add(-2 * inspectCacheTTL)
+	_, err = c.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, recorder.inspects, "the cache should expire")
+}
+
+func Test_IsRunning(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("exited container", func(t *testing.T) {
+		c := &DockerContainer{
+			ID:        "0123456789abcdef",
+			provider:  &DockerProvider{client: &logsClient{state: types.ContainerState{Status: "exited", ExitCode: 1}}},
+			logger:    Logger,
+			isRunning: true,
+		}
+
+		assert.False(t, IsRunning(ctx, c))
+		assert.False(t, c.IsRunning(), "the last known state should be refreshed")
+	})
+
+	t.Run("running container", func(t *testing.T) {
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: &logsClient{state: types.ContainerState{Status: "running", Running: true}}},
+			logger:   Logger,
+		}
+
+		assert.True(t, IsRunning(ctx, c))
+		assert.True(t, c.IsRunning(), "the last known state should be refreshed")
+	})
+}
+
+// restartClient restarts the containers
+type restartClient struct {
+	client.APIClient
+}
+
+func (c *restartClient) ContainerRestart(_ context.Context, _ string, _ container.StopOptions) error {
+	return nil
+}
+
+func Test_Restart(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("container not ready", func(t *testing.T) {
+		c := &DockerContainer{
+			ID:         "0123456789abcdef",
+			WaitingFor: failingStrategy{},
+			provider:   &DockerProvider{client: &restartClient{}},
+			logger:     TestLogger(t),
+		}
+
+		require.Error(t, c.Restart(ctx, nil))
+		assert.False(t, c.IsRunning(), "the container should only be running once it is ready")
+	})
+
+	t.Run("container ready", func(t *testing.T) {
+		c := &DockerContainer{
+			ID:       "0123456789abcdef",
+			provider: &DockerProvider{client: &restartClient{}},
+			logger:   TestLogger(t),
+		}
+
+		require.NoError(t, c.Restart(ctx, nil))
+		assert.True(t, c.IsRunning())
+	})
+}
+
+func Test_GPURequestDeviceRequest(t *testing.T) {
+	t.Run("count", func(t *testing.T) {
+		assert.Equal(t, container.DeviceRequest{
+			Count:        1,
+			Capabilities: [][]string{{"gpu"}},
+		}, GPURequest{Count: 1}.deviceRequest())
+	})
+
+	t.Run("device IDs with capabilities", func(t *testing.T) {
+		assert.Equal(t, container.DeviceRequest{
+			DeviceIDs:    []string{"0", "GPU-3a23c669"},
+			Capabilities: [][]string{{"gpu", "compute", "utility"}},
+		}, GPURequest{DeviceIDs: []string{"0", "GPU-3a23c669"}, Capabilities: []string{"compute", "utility"}}.deviceRequest())
+	})
+}
+
+func Test_ContainerRequestHash(t *testing.T) {
+	req := ContainerRequest{
+		Image:        "docker.io/nginx:alpine",
+		ExposedPorts: []string{"80/tcp"},
+		Env:          map[string]string{"A": "1", "B": "2"},
+		Labels:       map[string]string{"app": "nginx"},
+		WaitingFor:   wait.ForListeningPort("80/tcp"),
+	}
+
+	hash, err := req.hash()
+	require.NoError(t, err)
+
+	t.Run("same request has the same hash", func(t *testing.T) {
+		same := req
+		same.Env = map[string]string{"B": "2", "A": "1"}
+		same.WaitingFor = wait.ForLog("ready")
+
+		sameHash, err := same.hash()
+		require.NoError(t, err)
+		assert.Equal(t, hash, sameHash)
+	})
+
+	t.Run("labels reserved by Testcontainers are not part of the hash", func(t *testing.T) {
+		labelled := req
+		labelled.Labels = map[string]string{"app": "nginx"}
+		require.NoError(t, labelled.WithSessionLabels("session"))
+
+		labelledHash, err := labelled.hash()
+		require.NoError(t, err)
+		assert.Equal(t, hash, labelledHash)
+	})
+
+	t.Run("changed request has another hash", func(t *testing.T) {
+		changed := req
+		changed.Env = map[string]string{"A": "1", "B": "3"}
+
+		changedHash, err := changed.hash()
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, changedHash)
+	})
+
+	t.Run("changed working directory has another hash", func(t *testing.T) {
+		changed := req
+		changed.WorkingDir = "/tmp"
+
+		changedHash, err := changed.hash()
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, changedHash)
+	})
+
+	t.Run("files are hashed by content", func(t *testing.T) {
+		withReader := func(content string) ContainerRequest {
+			changed := req
+			changed.Files = []ContainerFile{{Reader: strings.NewReader(content), ContainerFilePath: "/etc/nginx/conf.d/default.conf"}}
+			return changed
+		}
+
+		first := withReader("server { listen 8080; }")
+		firstHash, err := first.hash()
+		require.NoError(t, err)
+
+		same := withReader("server { listen 8080; }")
+		sameHash, err := same.hash()
+		require.NoError(t, err)
+		assert.Equal(t, firstHash, sameHash)
+
+		changed := withReader("server { listen 9090; }")
+		changedHash, err := changed.hash()
+		require.NoError(t, err)
+		assert.NotEqual(t, firstHash, changedHash)
+
+		// the reader is rewound, so that the file can still be copied
+		content, err := io.ReadAll(first.Files[0].Reader)
+		require.NoError(t, err)
+		assert.Equal(t, "server { listen 8080; }", string(content))
+	})
+
+	t.Run("host files are hashed by content", func(t *testing.T) {
+		hostFile := filepath.Join(t.TempDir(), "default.conf")
+		changed := req
+		changed.Files = []ContainerFile{{HostFilePath: hostFile, ContainerFilePath: "/etc/nginx/conf.d/default.conf"}}
+
+		require.NoError(t, os.WriteFile(hostFile, []byte("server { listen 8080; }"), 0o644))
+		firstHash, err := changed.hash()
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(hostFile, []byte("server { listen 9090; }"), 0o644))
+		changedHash, err := changed.hash()
+		require.NoError(t, err)
+		assert.NotEqual(t, firstHash, changedHash)
+	})
+
+	t.Run("files read once cannot be reused", func(t *testing.T) {
+		changed := req
+		changed.Files = []ContainerFile{{Reader: io.MultiReader(strings.NewReader("content")), ContainerFilePath: "/data"}}
+
+		_, err := changed.hash()
+		require.ErrorIs(t, err, ErrReuseFileReader)
+	})
+}
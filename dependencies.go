@@ -0,0 +1,97 @@
+package testcontainers
+
+import (
+	"context"
+	"fmt"
+)
+
+// StartContainers creates and starts the containers of the requests in the order of their dependencies: a container
+// is started, and its wait strategy satisfied, before the containers of the requests naming it in their DependsOn.
+// The containers are returned in the order of the requests. When a container fails to start, the containers started
+// before it are returned with the error, so that they can be terminated
+func StartContainers(ctx context.Context, requests ...ContainerRequest) ([]Container, error) {
+	order, err := startOrder(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, len(requests))
+	started := make([]Container, 0, len(requests))
+	for _, idx := range order {
+		c, err := GenericContainer(ctx, GenericContainerRequest{
+			ContainerRequest: requests[idx],
+			Started:          true,
+		})
+		if c != nil {
+			started = append(started, c)
+		}
+		if err != nil {
+			return started, fmt.Errorf("%w: failed to start container %s", err, requests[idx].Name)
+		}
+		containers[idx] = c
+	}
+
+	return containers, nil
+}
+
+// startOrder returns the indexes of the requests sorted so that each request comes after its dependencies,
+// keeping the order of the requests otherwise
+func startOrder(requests []ContainerRequest) ([]int, error) {
+	byName := make(map[string]int, len(requests))
+	for idx, req := range requests {
+		if req.Name == "" {
+			continue
+		}
+		if _, ok := byName[req.Name]; ok {
+			return nil, fmt.Errorf("%w: %s, the name of the requests must be unique", ErrInvalidDependency, req.Name)
+		}
+		byName[req.Name] = idx
+	}
+
+	for _, req := range requests {
+		for _, dep := range req.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s, which is not the name of a request", ErrInvalidDependency, req.Name, dep)
+			}
+		}
+	}
+
+	order := make([]int, 0, len(requests))
+	done := make([]bool, len(requests))
+	for len(order) < len(requests) {
+		next := -1
+		for idx, req := range requests {
+			if done[idx] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range req.DependsOn {
+				if !done[byName[dep]] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = idx
+				break
+			}
+		}
+
+		// the remaining requests all depend on another remaining request
+		if next < 0 {
+			var remaining []string
+			for idx, req := range requests {
+				if !done[idx] {
+					remaining = append(remaining, req.Name)
+				}
+			}
+			return nil, fmt.Errorf("%w: between %v", ErrDependencyCycle, remaining)
+		}
+
+		done[next] = true
+		order = append(order, next)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,126 @@
+package testcontainers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func Test_StartOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []ContainerRequest
+		expected []int
+		err      error
+	}{
+		{
+			name: "chain given in reverse order",
+			requests: []ContainerRequest{
+				{Name: "app", DependsOn: []string{"cache"}},
+				{Name: "cache", DependsOn: []string{"db"}},
+				{Name: "db"},
+			},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name: "independent requests keep their order",
+			requests: []ContainerRequest{
+				{Name: "app", DependsOn: []string{"db", "cache"}},
+				{Name: "db"},
+				{},
+				{Name: "cache"},
+			},
+			expected: []int{1, 2, 3, 0},
+		},
+		{
+			name: "cycle",
+			requests: []ContainerRequest{
+				{Name: "db"},
+				{Name: "app", DependsOn: []string{"cache"}},
+				{Name: "cache", DependsOn: []string{"app"}},
+			},
+			err: ErrDependencyCycle,
+		},
+		{
+			name: "dependency on itself",
+			requests: []ContainerRequest{
+				{Name: "app", DependsOn: []string{"app"}},
+			},
+			err: ErrDependencyCycle,
+		},
+		{
+			name: "unknown dependency",
+			requests: []ContainerRequest{
+				{Name: "app", DependsOn: []string{"db"}},
+			},
+			err: ErrInvalidDependency,
+		},
+		{
+			name: "duplicate name",
+			requests: []ContainerRequest{
+				{Name: "db"},
+				{Name: "db"},
+			},
+			err: ErrInvalidDependency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := startOrder(tt.requests)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, order)
+		})
+	}
+}
+
+func TestStartContainers(t *testing.T) {
+	ctx := context.Background()
+	suffix := uuid.NewString()[:8]
+
+	request := func(name string, dependsOn ...string) ContainerRequest {
+		return ContainerRequest{
+			Image:     "docker.io/alpine:latest",
+			Name:      name + "-" + suffix,
+			DependsOn: dependsOn,
+			// the container is ready a while after it is started, so that a dependent started too early is detected
+			Cmd:        []string{"sh", "-c", "sleep 1; echo ready; sleep 300"},
+			WaitingFor: wait.ForLog("ready").WithStartupTimeout(10 * time.Second),
+		}
+	}
+
+	containers, err := StartContainers(ctx,
+		request("app", "cache-"+suffix),
+		request("cache", "db-"+suffix),
+		request("db"),
+	)
+	for _, c := range containers {
+		terminateContainerOnEnd(t, ctx, c)
+	}
+	require.NoError(t, err)
+	require.Len(t, containers, 3)
+
+	startedAt := make([]time.Time, len(containers))
+	for i, c := range containers {
+		inspect, err := c.Inspect(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "/"+[]string{"app", "cache", "db"}[i]+"-"+suffix, inspect.Name)
+
+		startedAt[i], err = time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+		require.NoError(t, err)
+	}
+
+	// each container is started once its dependency is ready
+	assert.True(t, startedAt[1].Sub(startedAt[2]) >= time.Second, "cache should be started once db is ready")
+	assert.True(t, startedAt[0].Sub(startedAt[1]) >= time.Second, "app should be started once cache is ready")
+}
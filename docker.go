@@ -6,13 +6,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,12 +29,16 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
 	"github.com/magiconair/properties"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
 	"github.com/moby/term"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
@@ -43,6 +52,30 @@ var (
 
 	logOnce                 sync.Once
 	ErrDuplicateMountTarget = errors.New("duplicate mount target detected")
+	ErrInvalidShmSize       = errors.New("invalid shm size")
+	ErrInvalidMemoryLimit   = errors.New("invalid memory limit")
+	ErrInvalidExtraHost     = errors.New("invalid extra host")
+	ErrReservedLabel        = errors.New("reserved label")
+	ErrInvalidImagePlatform = errors.New("invalid image platform")
+	ErrInvalidIPAM          = errors.New("invalid IPAM configuration")
+	ErrInvalidIPAddress     = errors.New("invalid IP address")
+	ErrInvalidRestartPolicy = errors.New("invalid restart policy")
+	ErrInvalidPullPolicy    = errors.New("invalid pull policy")
+	ErrImageNotPresent      = errors.New("image not present locally")
+	ErrInvalidTmpfs         = errors.New("invalid tmpfs mount")
+	ErrInvalidGPURequest    = errors.New("invalid GPU request")
+	ErrGPUsNotAvailable     = errors.New("GPUs not available")
+	ErrNetworkNotAttached   = errors.New("network not attached")
+	ErrInvalidUlimit        = errors.New("invalid ulimit")
+	ErrInvalidSecret        = errors.New("invalid secret")
+	ErrInvalidFile          = errors.New("invalid file")
+	ErrStartupCommandFailed = errors.New("startup command failed")
+	ErrInvalidImageDigest   = errors.New("invalid image digest")
+	ErrImageDigestMismatch  = errors.New("image digest mismatch")
+	ErrInvalidDependency    = errors.New("invalid dependency")
+	ErrDependencyCycle      = errors.New("dependency cycle")
+	ErrInvalidBuildSecret   = errors.New("invalid build secret")
+	ErrBuildKitNotAvailable = errors.New("BuildKit not available")
 )
 
 const (
@@ -50,8 +83,36 @@ const (
 	Podman        = "podman"
 	ReaperDefault = "reaper_default" // Default network name when bridge is not available
 	packagePath   = "github.com/testcontainers/testcontainers-go"
+
+	// minimumMemoryLimit is the lowest memory limit accepted by the Docker daemon, in bytes
+	minimumMemoryLimit = 6 * 1024 * 1024
+
+	// startErrorLogLines is the number of lines of the logs embedded in a ContainerStartError
+	startErrorLogLines = 50
 )
 
+// ContainerStartError is returned by Start when the container exited before being ready, e.g. because of a wrong
+// configuration. It embeds the last lines of the logs of the container, which can be read with errors.As
+type ContainerStartError struct {
+	ID       string // ID of the container
+	ExitCode int    // exit code of the process of the container
+	Logs     string // last lines of the logs of the container, stdout and stderr together
+	Err      error  // error of the wait strategy
+}
+
+func (e *ContainerStartError) Error() string {
+	// the ID is shortened as in the Docker CLI, but the error is also built by hand, e.g. in tests
+	id := e.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return fmt.Sprintf("container %s exited with code %d before being ready: %v\nlast lines of its logs:\n%s", id, e.ExitCode, e.Err, e.Logs)
+}
+
+func (e *ContainerStartError) Unwrap() error {
+	return e.Err
+}
+
 // DockerContainer represents a container started using Docker
 type DockerContainer struct {
 	// Container ID from Docker
@@ -66,9 +127,20 @@ type DockerContainer struct {
 	terminationSignal chan bool
 	skipReaper        bool
 	consumers         []LogConsumer
+	consumersMx       sync.Mutex
 	raw               *types.ContainerJSON
 	stopProducer      chan bool
 	logger            Logging
+	lifecycleHooks    ContainerLifecycleHooks
+	logDumpDir        string
+	stopSignal        string
+	startupCommand    []string
+	terminated        bool
+
+	// the raw response of the last inspect, returned by Inspect until it expires or the state of the container changes
+	inspectMx    sync.Mutex
+	inspectCache []byte
+	inspectedAt  time.Time
 }
 
 // SetLogger sets the logger for the container
@@ -85,6 +157,8 @@ func (c *DockerContainer) GetContainerID() string {
 	return c.ID
 }
 
+// IsRunning returns whether the container is running, as last known by Testcontainers:
+// it is updated by Start, Stop, Restart, Terminate and State, the latter inspecting the container
 func (c *DockerContainer) IsRunning() bool {
 	return c.isRunning
 }
@@ -110,6 +184,12 @@ func (c *DockerContainer) Endpoint(ctx context.Context, proto string) (string, e
 // PortEndpoint gets proto://host:port string for the given exposed port
 // Will returns just host:port if proto is ""
 func (c *DockerContainer) PortEndpoint(ctx context.Context, port nat.Port, proto string) (string, error) {
+	return c.PortEndpointWithOptions(ctx, port, EndpointOptions{Scheme: proto})
+}
+
+// PortEndpointWithOptions gets the endpoint of the given exposed port, e.g. https://host:port/healthz,
+// bracketing the host if it is an IPv6 address
+func (c *DockerContainer) PortEndpointWithOptions(ctx context.Context, port nat.Port, options EndpointOptions) (string, error) {
 	host, err := c.Host(ctx)
 	if err != nil {
 		return "", err
@@ -120,19 +200,28 @@ func (c *DockerContainer) PortEndpoint(ctx context.Context, port nat.Port, proto
 		return "", err
 	}
 
-	protoFull := ""
-	if proto != "" {
-		protoFull = fmt.Sprintf("%s://", proto)
+	return buildEndpoint(host, outerPort, options), nil
+}
+
+func buildEndpoint(host string, port nat.Port, options EndpointOptions) string {
+	scheme := ""
+	if options.Scheme != "" {
+		scheme = fmt.Sprintf("%s://", options.Scheme)
+	}
+
+	path := ""
+	if options.Path != "" {
+		path = "/" + strings.TrimPrefix(options.Path, "/")
 	}
 
-	return fmt.Sprintf("%s%s:%s", protoFull, host, outerPort.Port()), nil
+	return scheme + net.JoinHostPort(host, port.Port()) + path
 }
 
 // Host gets host (ip or name) of the docker daemon where the container port is exposed
 // Warning: this is based on your Docker host setting. Will fail if using an SSH tunnel
 // You can use the "TC_HOST" env variable to set this yourself
 func (c *DockerContainer) Host(ctx context.Context) (string, error) {
-	host, err := c.provider.daemonHost(ctx)
+	host, err := c.provider.DaemonHost(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -145,7 +234,7 @@ func (c *DockerContainer) MappedPort(ctx context.Context, port nat.Port) (nat.Po
 	if err != nil {
 		return "", err
 	}
-	if inspect.ContainerJSONBase.HostConfig.NetworkMode == "host" {
+	if inspect.ContainerJSONBase.HostConfig.NetworkMode.IsHost() {
 		return port, nil
 	}
 	ports, err := c.Ports(ctx)
@@ -170,11 +259,21 @@ func (c *DockerContainer) MappedPort(ctx context.Context, port nat.Port) (nat.Po
 }
 
 // Ports gets the exposed ports for the container.
+// With the host network mode, the ports are not mapped, so each exposed port is returned as bound to itself
 func (c *DockerContainer) Ports(ctx context.Context) (nat.PortMap, error) {
 	inspect, err := c.inspectContainer(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	if inspect.HostConfig.NetworkMode.IsHost() {
+		ports := make(nat.PortMap, len(inspect.Config.ExposedPorts))
+		for port := range inspect.Config.ExposedPorts {
+			ports[port] = []nat.PortBinding{{HostPort: port.Port()}}
+		}
+		return ports, nil
+	}
+
 	return inspect.NetworkSettings.Ports, nil
 }
 
@@ -189,6 +288,16 @@ func (c *DockerContainer) Start(ctx context.Context) error {
 	c.logger.Printf("Starting container id: %s image: %s", shortID, c.Image)
 
 	if err := c.provider.client.ContainerStart(ctx, c.ID, types.ContainerStartOptions{}); err != nil {
+		// the daemon finds no driver for the GPUs when the NVIDIA Container Toolkit is not installed
+		if strings.Contains(err.Error(), "could not select device driver") {
+			return fmt.Errorf("%w: is the NVIDIA Container Toolkit installed on the Docker host? %s", ErrGPUsNotAvailable, err)
+		}
+		return err
+	}
+	c.invalidateInspectCache()
+
+	if err := c.runStartupCommand(ctx); err != nil {
+		c.dumpLogs(ctx)
 		return err
 	}
 
@@ -196,14 +305,113 @@ func (c *DockerContainer) Start(ctx context.Context) error {
 	if c.WaitingFor != nil {
 		c.logger.Printf("Waiting for container id %s image: %s", shortID, c.Image)
 		if err := c.WaitingFor.WaitUntilReady(ctx, c); err != nil {
-			return err
+			c.dumpLogs(ctx)
+			return c.startError(ctx, err)
 		}
 	}
 	c.logger.Printf("Container is ready id: %s image: %s", shortID, c.Image)
 	c.isRunning = true
+
+	for _, hook := range c.lifecycleHooks.PostStarts {
+		if err := hook(ctx, c); err != nil {
+			return fmt.Errorf("post start hook of container %s: %w", shortID, err)
+		}
+	}
+
 	return nil
 }
 
+// runStartupCommand executes the startup command of the container, if any, e.g. to initialize a database before its
+// wait strategy makes sense. An ErrStartupCommandFailed error embedding the output of the command is returned when
+// it exits with a non-zero code
+func (c *DockerContainer) runStartupCommand(ctx context.Context) error {
+	if len(c.startupCommand) == 0 {
+		return nil
+	}
+
+	c.logger.Printf("Running startup command of container id: %s image: %s", c.ID[:12], c.Image)
+	exitCode, stdout, stderr, err := c.ExecWithOptions(ctx, c.startupCommand, ExecOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot run startup command %v of container %s: %w", c.startupCommand, c.ID[:12], err)
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	var output strings.Builder
+	_, _ = io.Copy(&output, stdout)
+	_, _ = io.Copy(&output, stderr)
+	return fmt.Errorf("%w: %v exited with code %d in container %s: %s",
+		ErrStartupCommandFailed, c.startupCommand, exitCode, c.ID[:12], strings.TrimSpace(output.String()))
+}
+
+// startError returns a ContainerStartError embedding the last lines of the logs of the container when it exited
+// before being ready, or the error of the wait strategy as is when it is still running
+func (c *DockerContainer) startError(ctx context.Context, err error) error {
+	c.invalidateInspectCache()
+	state, stateErr := c.State(ctx)
+	if stateErr != nil || state.Running || state.Status == "created" {
+		return err
+	}
+
+	startErr := &ContainerStartError{
+		ID:       c.ID,
+		ExitCode: state.ExitCode,
+		Err:      err,
+	}
+
+	logs, logsErr := c.LogsWithOptions(ctx, WithTail(startErrorLogLines))
+	if logsErr != nil {
+		c.logger.Printf("Failed to get the logs of container %s: %v", c.ID[:12], logsErr)
+		return startErr
+	}
+	defer logs.Close()
+
+	b, logsErr := io.ReadAll(logs)
+	if logsErr != nil {
+		c.logger.Printf("Failed to read the logs of container %s: %v", c.ID[:12], logsErr)
+	}
+	startErr.Logs = string(b)
+
+	return startErr
+}
+
+// dumpLogs writes the logs of the container to a file of the log dump directory, named after the container and its
+// session, e.g. to debug a failure in CI. It does nothing without log dump directory, and its errors are only logged,
+// so that they do not hide the failure
+func (c *DockerContainer) dumpLogs(ctx context.Context) {
+	if c.logDumpDir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(c.logDumpDir, 0o755); err != nil {
+		c.logger.Printf("Failed to create the log dump directory %s: %v", c.logDumpDir, err)
+		return
+	}
+
+	logs, err := c.Logs(ctx)
+	if err != nil {
+		c.logger.Printf("Failed to get the logs of container %s: %v", c.ID[:12], err)
+		return
+	}
+	defer logs.Close()
+
+	path := filepath.Join(c.logDumpDir, fmt.Sprintf("%s-%s.log", c.ID[:12], c.SessionID()))
+	f, err := os.Create(path)
+	if err != nil {
+		c.logger.Printf("Failed to create the log dump %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, logs); err != nil {
+		c.logger.Printf("Failed to write the log dump %s: %v", path, err)
+		return
+	}
+
+	c.logger.Printf("Logs of container %s dumped to %s", c.ID[:12], path)
+}
+
 // Stop will stop an already started container
 //
 // In case the container fails to stop
@@ -227,25 +435,118 @@ func (c *DockerContainer) Stop(ctx context.Context, timeout *time.Duration) erro
 	if err := c.provider.client.ContainerStop(ctx, c.ID, options); err != nil {
 		return err
 	}
+	c.invalidateInspectCache()
 
 	c.logger.Printf("Container is stopped id: %s image: %s", shortID, c.Image)
 	c.isRunning = false
 	return nil
 }
 
+// Restart will stop the container, waiting for the given timeout before killing it, and start it again.
+// If the container was created with a Wait Strategy, it is used to wait for the container to be ready again
+func (c *DockerContainer) Restart(ctx context.Context, timeout *time.Duration) error {
+	shortID := c.ID[:12]
+	c.logger.Printf("Restarting container id: %s image: %s", shortID, c.Image)
+
+	var options container.StopOptions
+
+	if timeout != nil {
+		timeoutSeconds := int(timeout.Seconds())
+		options.Timeout = &timeoutSeconds
+	}
+
+	if err := c.provider.client.ContainerRestart(ctx, c.ID, options); err != nil {
+		return err
+	}
+	c.invalidateInspectCache()
+
+	if c.WaitingFor != nil {
+		c.logger.Printf("Waiting for container id %s image: %s", shortID, c.Image)
+		if err := c.WaitingFor.WaitUntilReady(ctx, c); err != nil {
+			return err
+		}
+	}
+	c.logger.Printf("Container is ready id: %s image: %s", shortID, c.Image)
+	c.isRunning = true
+	return nil
+}
+
+// Update changes the resources, e.g. the memory limit or the CPU quota, and the restart policy of the container
+// without recreating it, e.g. to throttle a container during a load test. The zero values are left unchanged
+func (c *DockerContainer) Update(ctx context.Context, config container.UpdateConfig) error {
+	defer c.invalidateInspectCache()
+
+	resp, err := c.provider.client.ContainerUpdate(ctx, c.ID, config)
+	if err != nil {
+		return fmt.Errorf("error updating container %s: %w", c.ID[:12], err)
+	}
+	for _, warning := range resp.Warnings {
+		c.logger.Printf("Warning updating container %s: %s", c.ID[:12], warning)
+	}
+
+	return nil
+}
+
 // Terminate is used to kill the container. It is usually triggered by as defer function.
-func (c *DockerContainer) Terminate(ctx context.Context) error {
+// By default, the container is killed and removed along with its anonymous volumes,
+// which can be changed with the given options, e.g. WithStopTimeout to stop it gracefully first.
+// A container created with a StopSignal is always stopped gracefully first, with that signal.
+// Terminate is idempotent: terminating a container which is already terminated or removed is not an error.
+func (c *DockerContainer) Terminate(ctx context.Context, opts ...TerminateOption) error {
+	if c.terminated {
+		return nil
+	}
+
+	options := &terminateOptions{
+		RemoveVolumes: true,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	for _, hook := range c.lifecycleHooks.PreTerminates {
+		if err := hook(ctx, c); err != nil {
+			return fmt.Errorf("pre terminate hook of container %s: %w", c.ID[:12], err)
+		}
+	}
+
 	select {
 	// close reaper if it was created
 	case c.terminationSignal <- true:
 	default:
 	}
+
+	// the logs of a container which exited with an error are dumped before it is removed
+	if c.logDumpDir != "" {
+		if state, err := c.State(ctx); err == nil && !state.Running && state.ExitCode != 0 {
+			c.dumpLogs(ctx)
+		}
+	}
+
+	// a container with a stop signal is stopped with it, so that it can shut down gracefully, instead of being killed
+	if options.StopTimeout != nil || c.stopSignal != "" {
+		// a container that is already gone is reported by the removal below
+		if err := c.Stop(ctx, options.StopTimeout); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+	}
+
 	err := c.provider.client.ContainerRemove(ctx, c.GetContainerID(), types.ContainerRemoveOptions{
-		RemoveVolumes: true,
+		RemoveVolumes: options.RemoveVolumes,
 		Force:         true,
 	})
-	if err != nil {
-		return err
+	// a container which is not found is already removed, e.g. by another call or by its AutoRemove
+	if err != nil && !errdefs.IsNotFound(err) {
+		// the removal may already be in progress, e.g. for an AutoRemove container, so it is waited for
+		if !options.WaitForRemoval || !errdefs.IsConflict(err) {
+			return err
+		}
+	}
+
+	if options.WaitForRemoval {
+		if err := c.waitForRemoval(ctx); err != nil {
+			return err
+		}
 	}
 
 	if c.imageWasBuilt {
@@ -264,9 +565,29 @@ func (c *DockerContainer) Terminate(ctx context.Context) error {
 
 	c.sessionID = uuid.UUID{}
 	c.isRunning = false
+	c.terminated = true
 	return nil
 }
 
+// waitForRemoval polls the container until Docker reports it as not found
+func (c *DockerContainer) waitForRemoval(ctx context.Context) error {
+	for {
+		_, err := c.provider.client.ContainerInspect(ctx, c.GetContainerID())
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: container %s was not removed", ctx.Err(), c.ID[:12])
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 // update container raw info
 func (c *DockerContainer) inspectRawContainer(ctx context.Context) (*types.ContainerJSON, error) {
 	inspect, err := c.provider.client.ContainerInspect(ctx, c.ID)
@@ -277,6 +598,43 @@ func (c *DockerContainer) inspectRawContainer(ctx context.Context) (*types.Conta
 	return c.raw, nil
 }
 
+// inspectCacheTTL is the time during which Inspect returns the cached response of the last inspect
+const inspectCacheTTL = time.Second
+
+// Inspect returns the full inspect of the container. The response is cached for a second, so that hot loops
+// do not inspect the container again and again: it may then be slightly stale, e.g. for the state or the health
+// of the container, which State returns without cache. The cache is invalidated when the container is started,
+// stopped, restarted, or connected to or disconnected from a network. Each call returns a copy of the response,
+// which the caller may modify
+func (c *DockerContainer) Inspect(ctx context.Context) (*types.ContainerJSON, error) {
+	c.inspectMx.Lock()
+	defer c.inspectMx.Unlock()
+
+	if c.inspectCache == nil || time.Since(c.inspectedAt) > inspectCacheTTL {
+		_, raw, err := c.provider.client.ContainerInspectWithRaw(ctx, c.ID, false)
+		if err != nil {
+			return nil, err
+		}
+		c.inspectCache = raw
+		c.inspectedAt = time.Now()
+	}
+
+	// the response is decoded for each call, so that the callers do not share it
+	var inspect types.ContainerJSON
+	if err := json.Unmarshal(c.inspectCache, &inspect); err != nil {
+		return nil, err
+	}
+
+	return &inspect, nil
+}
+
+func (c *DockerContainer) invalidateInspectCache() {
+	c.inspectMx.Lock()
+	defer c.inspectMx.Unlock()
+
+	c.inspectCache = nil
+}
+
 func (c *DockerContainer) inspectContainer(ctx context.Context) (*types.ContainerJSON, error) {
 	inspect, err := c.provider.client.ContainerInspect(ctx, c.ID)
 	if err != nil {
@@ -289,13 +647,33 @@ func (c *DockerContainer) inspectContainer(ctx context.Context) (*types.Containe
 // Logs will fetch both STDOUT and STDERR from the current container. Returns a
 // ReadCloser and leaves it up to the caller to extract what it wants.
 func (c *DockerContainer) Logs(ctx context.Context) (io.ReadCloser, error) {
+	return c.LogsWithOptions(ctx)
+}
+
+// LogsWithOptions fetches the logs of the container like Logs, filtered by the given options,
+// e.g. to only get the last lines of a long-running container
+func (c *DockerContainer) LogsWithOptions(ctx context.Context, opts ...LogsOption) (io.ReadCloser, error) {
 
 	const streamHeaderSize = 8
 
+	logsOpts := &logsOptions{}
+	for _, opt := range opts {
+		opt(logsOpts)
+	}
+
 	options := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 	}
+	if logsOpts.Since != nil {
+		options.Since = fmt.Sprintf("%d.%09d", logsOpts.Since.Unix(), logsOpts.Since.Nanosecond())
+	}
+	if logsOpts.Until != nil {
+		options.Until = fmt.Sprintf("%d.%09d", logsOpts.Until.Unix(), logsOpts.Until.Nanosecond())
+	}
+	if logsOpts.Tail != nil && *logsOpts.Tail >= 0 {
+		options.Tail = strconv.Itoa(*logsOpts.Tail)
+	}
 
 	rc, err := c.provider.client.ContainerLogs(ctx, c.ID, options)
 	if err != nil {
@@ -343,13 +721,34 @@ func (c *DockerContainer) Logs(ctx context.Context) (io.ReadCloser, error) {
 // FollowOutput adds a LogConsumer to be sent logs from the container's
 // STDOUT and STDERR
 func (c *DockerContainer) FollowOutput(consumer LogConsumer) {
-	if c.consumers == nil {
-		c.consumers = []LogConsumer{
-			consumer,
+	c.consumersMx.Lock()
+	defer c.consumersMx.Unlock()
+
+	c.consumers = append(c.consumers, consumer)
+}
+
+// RemoveConsumer stops forwarding the logs to a consumer added with FollowOutput, while the log producer keeps running
+// for the other consumers. Consumers are compared with ==, so they should be pointers
+func (c *DockerContainer) RemoveConsumer(consumer LogConsumer) {
+	c.consumersMx.Lock()
+	defer c.consumersMx.Unlock()
+
+	// a new slice is allocated, so that the log producer can keep iterating over the previous one
+	consumers := make([]LogConsumer, 0, len(c.consumers))
+	for _, cons := range c.consumers {
+		if cons != consumer {
+			consumers = append(consumers, cons)
 		}
-	} else {
-		c.consumers = append(c.consumers, consumer)
 	}
+	c.consumers = consumers
+}
+
+// logConsumers returns the consumers to forward a log to
+func (c *DockerContainer) logConsumers() []LogConsumer {
+	c.consumersMx.Lock()
+	defer c.consumersMx.Unlock()
+
+	return c.consumers
 }
 
 // Name gets the name of the container.
@@ -361,7 +760,8 @@ func (c *DockerContainer) Name(ctx context.Context) (string, error) {
 	return inspect.Name, nil
 }
 
-// State returns container's running state
+// State returns the state of the container inspected from Docker, e.g. whether it is running, its exit code,
+// or whether it was OOM killed
 func (c *DockerContainer) State(ctx context.Context) (*types.ContainerState, error) {
 	inspect, err := c.inspectRawContainer(ctx)
 	if err != nil {
@@ -370,6 +770,8 @@ func (c *DockerContainer) State(ctx context.Context) (*types.ContainerState, err
 		}
 		return nil, err
 	}
+	// the container may have exited on its own since it was started
+	c.isRunning = inspect.State.Running
 	return inspect.State, nil
 }
 
@@ -429,6 +831,22 @@ func (c *DockerContainer) ContainerIPs(ctx context.Context) ([]string, error) {
 	return ips, nil
 }
 
+// NetworkIP gets the IP address of the container on the given network, e.g. for a container attached to several
+// networks. An ErrNetworkNotAttached error is returned if the container is not attached to that network
+func (c *DockerContainer) NetworkIP(ctx context.Context, networkName string) (string, error) {
+	inspect, err := c.inspectContainer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	nw, ok := inspect.NetworkSettings.Networks[networkName]
+	if !ok {
+		return "", fmt.Errorf("%w: container %s is not attached to network %s", ErrNetworkNotAttached, c.ID[:12], networkName)
+	}
+
+	return nw.IPAddress, nil
+}
+
 // NetworkAliases gets the aliases of the container for the networks it is attached to.
 func (c *DockerContainer) NetworkAliases(ctx context.Context) (map[string][]string, error) {
 	inspect, err := c.inspectContainer(ctx)
@@ -447,6 +865,24 @@ func (c *DockerContainer) NetworkAliases(ctx context.Context) (map[string][]stri
 	return a, nil
 }
 
+// ConnectToNetwork connects the running container to the given network, with the given aliases,
+// e.g. to restore the connectivity to a dependency after DisconnectFromNetwork
+func (c *DockerContainer) ConnectToNetwork(ctx context.Context, networkName string, aliases ...string) error {
+	defer c.invalidateInspectCache()
+
+	return c.provider.client.NetworkConnect(ctx, networkName, c.ID, &network.EndpointSettings{
+		Aliases: aliases,
+	})
+}
+
+// DisconnectFromNetwork disconnects the running container from the given network,
+// e.g. to simulate a network partition
+func (c *DockerContainer) DisconnectFromNetwork(ctx context.Context, networkName string) error {
+	defer c.invalidateInspectCache()
+
+	return c.provider.client.NetworkDisconnect(ctx, networkName, c.ID, false)
+}
+
 func (c *DockerContainer) Exec(ctx context.Context, cmd []string, options ...tcexec.ProcessOption) (int, io.Reader, error) {
 	cli := c.provider.client
 	response, err := cli.ContainerExecCreate(ctx, c.ID, types.ExecConfig{
@@ -472,22 +908,82 @@ func (c *DockerContainer) Exec(ctx context.Context, cmd []string, options ...tce
 		o.Apply(opt)
 	}
 
-	var exitCode int
+	exitCode, err := c.waitForExec(ctx, response.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return exitCode, opt.Reader, nil
+}
+
+// ExecWithOptions executes a command in the container, returning its exit code, standard output and standard error
+func (c *DockerContainer) ExecWithOptions(ctx context.Context, cmd []string, options ExecOptions) (int, io.Reader, io.Reader, error) {
+	env := []string{}
+	for envKey, envVar := range options.Env {
+		env = append(env, envKey+"="+envVar)
+	}
+
+	cli := c.provider.client
+	response, err := cli.ContainerExecCreate(ctx, c.ID, types.ExecConfig{
+		Cmd:          cmd,
+		Env:          env,
+		WorkingDir:   options.WorkingDir,
+		User:         options.User,
+		Detach:       false,
+		AttachStdin:  options.AttachStdin || options.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	hijack, err := cli.ContainerExecAttach(ctx, response.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer hijack.Close()
+
+	if options.AttachStdin || options.Stdin != nil {
+		// the input is written while the output is read, so that neither of them blocks the command
+		go func() {
+			if options.Stdin != nil {
+				// the command may exit without reading all of its input, which is not an error
+				_, _ = io.Copy(hijack.Conn, options.Stdin)
+			}
+			// closing the standard input lets the command know that there is nothing left to read
+			_ = hijack.CloseWrite()
+		}()
+	}
+
+	// the output is multiplexed, as no TTY is allocated, until the command exits
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, hijack.Reader); err != nil {
+		return 0, nil, nil, err
+	}
+
+	exitCode, err := c.waitForExec(ctx, response.ID)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return exitCode, &stdout, &stderr, nil
+}
+
+// waitForExec waits until the given exec instance is not running anymore, returning its exit code
+func (c *DockerContainer) waitForExec(ctx context.Context, execID string) (int, error) {
 	for {
-		execResp, err := cli.ContainerExecInspect(ctx, response.ID)
+		execResp, err := c.provider.client.ContainerExecInspect(ctx, execID)
 		if err != nil {
-			return 0, nil, err
+			return 0, err
 		}
 
 		if !execResp.Running {
-			exitCode = execResp.ExitCode
-			break
+			return execResp.ExitCode, nil
 		}
 
 		time.Sleep(100 * time.Millisecond)
 	}
-
-	return exitCode, opt.Reader, nil
 }
 
 type FileFromContainer struct {
@@ -503,6 +999,33 @@ func (fc *FileFromContainer) Close() error {
 	return (*fc.underlying).Close()
 }
 
+// Commit creates an image with the given reference from the current state of the container, e.g. to reuse a seeded
+// database in other containers, and returns the ID of the image. The container is paused during the commit.
+// The image is not removed at the end of the session
+func (c *DockerContainer) Commit(ctx context.Context, imageRef string, opts ...CommitOption) (string, error) {
+	commitOpts := &commitOptions{}
+	for _, opt := range opts {
+		opt(commitOpts)
+	}
+
+	resp, err := c.provider.client.ContainerCommit(ctx, c.ID, types.ContainerCommitOptions{
+		Reference: imageRef,
+		Author:    commitOpts.Author,
+		Comment:   commitOpts.Message,
+		Changes:   commitOpts.Changes,
+		Pause:     true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error committing container %s to %s: %w", c.ID[:12], imageRef, err)
+	}
+
+	return resp.ID, nil
+}
+
+var _ wait.FileStrategyTarget = (*DockerContainer)(nil)
+
+// CopyFileFromContainer returns a reader for the content of a file in the container, which must be closed by the caller.
+// A not found error is returned if the file does not exist
 func (c *DockerContainer) CopyFileFromContainer(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	r, _, err := c.provider.client.CopyFromContainer(ctx, c.ID, filePath)
 	if err != nil {
@@ -512,11 +1035,17 @@ func (c *DockerContainer) CopyFileFromContainer(ctx context.Context, filePath st
 
 	// if we got here we have exactly one file in the TAR-stream
 	// so we advance the index by one so the next call to Read will start reading it
-	_, err = tarReader.Next()
+	header, err := tarReader.Next()
 	if err != nil {
+		_ = r.Close()
 		return nil, err
 	}
 
+	if header.Typeflag == tar.TypeDir {
+		_ = r.Close()
+		return nil, fmt.Errorf("path %s is a directory, not a file", filePath)
+	}
+
 	ret := &FileFromContainer{
 		underlying: &r,
 		tarreader:  tarReader,
@@ -526,7 +1055,8 @@ func (c *DockerContainer) CopyFileFromContainer(ctx context.Context, filePath st
 }
 
 // CopyDirToContainer copies the contents of a directory to a parent path in the container. This parent path must exist in the container first
-// as we cannot create it
+// as we cannot create it. Nested directories are copied recursively, and symlinks to files are followed, copying the file they point to,
+// while symlinks to directories are reported as an error
 func (c *DockerContainer) CopyDirToContainer(ctx context.Context, hostDirPath string, containerParentPath string, fileMode int64) error {
 	dir, err := isDir(hostDirPath)
 	if err != nil {
@@ -576,6 +1106,33 @@ func (c *DockerContainer) CopyToContainer(ctx context.Context, fileContent []byt
 	return c.provider.client.CopyToContainer(ctx, c.ID, filepath.Dir(containerFilePath), buffer, types.CopyToContainerOptions{})
 }
 
+// CopyReaderToContainer streams size bytes of the reader to a file in container, without buffering them in memory
+func (c *DockerContainer) CopyReaderToContainer(ctx context.Context, reader io.Reader, size int64, containerFilePath string, fileMode int64) error {
+	if size < 0 {
+		return fmt.Errorf("invalid size %d for %s", size, containerFilePath)
+	}
+
+	tarStream := tarReader(reader, size, containerFilePath, fileMode)
+	defer tarStream.Close()
+
+	return c.provider.client.CopyToContainer(ctx, c.ID, filepath.Dir(containerFilePath), tarStream, types.CopyToContainerOptions{})
+}
+
+// copySecrets writes the secrets to files of the /run/secrets directory of the container, as the secrets of
+// Docker swarm, so that the applications reading them can be tested without a swarm. The /run directory must exist
+func (c *DockerContainer) copySecrets(ctx context.Context, secrets map[string]string) error {
+	buffer, err := tarSecrets(secrets)
+	if err != nil {
+		return err
+	}
+
+	if err := c.provider.client.CopyToContainer(ctx, c.ID, "/run", buffer, types.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("can't copy the secrets to container: %w", err)
+	}
+
+	return nil
+}
+
 // StartLogProducer will start a concurrent process that will continuously read logs
 // from the container and will send them to each added LogConsumer
 func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
@@ -645,7 +1202,7 @@ func (c *DockerContainer) StartLogProducer(ctx context.Context) error {
 					_, _ = fmt.Fprintf(os.Stderr, "error occurred reading log with known length %s", err.Error())
 					continue
 				}
-				for _, c := range c.consumers {
+				for _, c := range c.logConsumers() {
 					c.Accept(Log{
 						LogType: logTypes[logType],
 						Content: b,
@@ -674,6 +1231,11 @@ type DockerNetwork struct {
 	terminationSignal chan bool
 }
 
+// GetNetworkID returns the ID of the network from Docker
+func (n *DockerNetwork) GetNetworkID() string {
+	return n.ID
+}
+
 // Remove is used to remove the network. It is usually triggered by as defer function.
 func (n *DockerNetwork) Remove(ctx context.Context) error {
 	select {
@@ -698,9 +1260,13 @@ func (p *DockerProvider) Client() client.APIClient {
 	return p.client
 }
 
-// SetClient sets the docker client to be used by the provider
+// SetClient sets the docker client to be used by the provider, bounding its calls by the operation timeout, if any
 func (p *DockerProvider) SetClient(c client.APIClient) {
-	p.client = c
+	if p.DockerProviderOptions == nil {
+		p.client = c
+		return
+	}
+	p.client = withOperationTimeout(c, p.operationTimeout)
 }
 
 var _ ContainerProvider = (*DockerProvider)(nil)
@@ -711,12 +1277,15 @@ type TestContainersConfig struct {
 	TLSVerify      int    `properties:"docker.tls.verify,default=0"`
 	CertPath       string `properties:"docker.cert.path,default="`
 	RyukPrivileged bool   `properties:"ryuk.container.privileged,default=false"`
+	RyukDisabled   bool   `properties:"ryuk.disabled,default=false"`
 }
 
 type (
 	// DockerProviderOptions defines options applicable to DockerProvider
 	DockerProviderOptions struct {
 		defaultBridgeNetworkName string
+		clientVersion            string
+		operationTimeout         time.Duration
 		*GenericProviderOptions
 	}
 
@@ -756,18 +1325,70 @@ func WithDefaultBridgeNetwork(bridgeNetworkName string) DockerProviderOption {
 	})
 }
 
-func NewDockerClient() (cli *client.Client, host string, tcConfig TestContainersConfig, err error) {
-	tcConfig = configureTC()
+// WithDockerClientVersion pins the version of the Docker API used by the provider, e.g. "1.39" for a legacy daemon
+// rejecting the negotiated version as too new. It takes precedence over the DOCKER_API_VERSION environment variable
+func WithDockerClientVersion(version string) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.clientVersion = version
+	})
+}
 
-	host = tcConfig.Host
+// WithOperationTimeout bounds each call of the provider to the Docker daemon, e.g. creating, starting or inspecting
+// a container, so that a wedged daemon fails the call instead of hanging the test until its own timeout.
+// The streaming calls, such as pulling an image or following logs, are not bounded. There is no limit by default
+func WithOperationTimeout(timeout time.Duration) DockerProviderOption {
+	return DockerProviderOptionFunc(func(opts *DockerProviderOptions) {
+		opts.operationTimeout = timeout
+	})
+}
 
-	opts := []client.Opt{client.FromEnv}
-	if host != "" {
-		opts = append(opts, client.WithHost(host))
+// dockerSocketPaths returns the usual locations of the Docker socket, and of the Docker compatible sockets, in order of
+// precedence: the Docker socket, the socket of a rootless Docker, the socket of Docker Desktop, the socket of a
+// rootless Podman, and the socket of a rootful Podman
+func dockerSocketPaths() []string {
+	paths := []string{defaultDockerSocketPath}
 
-		// for further informacion, read https://docs.docker.com/engine/security/protect-access/
-		if tcConfig.TLSVerify == 1 {
-			cacertPath := filepath.Join(tcConfig.CertPath, "ca.pem")
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir != "" {
+		paths = append(paths, filepath.Join(runtimeDir, "docker.sock"))
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		paths = append(paths, filepath.Join(home, ".docker", "run", "docker.sock"))
+	}
+	if runtimeDir != "" {
+		paths = append(paths, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+
+	return append(paths, "/run/podman/podman.sock")
+}
+
+// detectDockerSocket returns the first of the given paths which is a socket, or an empty string when none of them is
+func detectDockerSocket(paths []string) string {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// NewDockerClient creates a Docker client from the environment and the Testcontainers properties file.
+// When neither of them sets the Docker host, the first socket found at the usual locations is used, e.g. the one of
+// a rootless Podman at $XDG_RUNTIME_DIR/podman/podman.sock.
+// The given options are applied last, e.g. client.WithVersion to pin the API version instead of negotiating it
+func NewDockerClient(clientOpts ...client.Opt) (cli *client.Client, host string, tcConfig TestContainersConfig, err error) {
+	tcConfig = configureTC()
+
+	host = tcConfig.Host
+
+	opts := []client.Opt{client.FromEnv}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+
+		// for further informacion, read https://docs.docker.com/engine/security/protect-access/
+		if tcConfig.TLSVerify == 1 {
+			cacertPath := filepath.Join(tcConfig.CertPath, "ca.pem")
 			certPath := filepath.Join(tcConfig.CertPath, "cert.pem")
 			keyPath := filepath.Join(tcConfig.CertPath, "key.pem")
 
@@ -775,8 +1396,12 @@ func NewDockerClient() (cli *client.Client, host string, tcConfig TestContainers
 		}
 	} else if dockerHostEnv := os.Getenv("DOCKER_HOST"); dockerHostEnv != "" {
 		host = dockerHostEnv
+	} else if socketPath := detectDockerSocket(dockerSocketPaths()); socketPath != "" {
+		// e.g. the Docker compatible socket of a rootless Podman, which the client does not find by itself
+		host = "unix://" + socketPath
+		opts = append(opts, client.WithHost(host))
 	} else {
-		host = "unix:///var/run/docker.sock"
+		host = "unix://" + defaultDockerSocketPath
 	}
 
 	opts = append(opts, client.WithHTTPHeaders(
@@ -784,6 +1409,7 @@ func NewDockerClient() (cli *client.Client, host string, tcConfig TestContainers
 			"x-tc-sid": sessionID().String(),
 		}),
 	)
+	opts = append(opts, clientOpts...)
 
 	cli, err = client.NewClientWithOpts(opts...)
 
@@ -808,7 +1434,13 @@ func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error
 		provOpts[idx].ApplyDockerTo(o)
 	}
 
-	c, host, tcConfig, err := NewDockerClient()
+	// the API version is negotiated with the daemon, unless it is pinned with DOCKER_API_VERSION or WithDockerClientVersion
+	var clientOpts []client.Opt
+	if o.clientVersion != "" {
+		clientOpts = append(clientOpts, client.WithVersion(o.clientVersion))
+	}
+
+	c, host, tcConfig, err := NewDockerClient(clientOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -816,7 +1448,7 @@ func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error
 	_, err = c.Ping(context.TODO())
 	if err != nil {
 		// fallback to environment
-		c, err = client.NewClientWithOpts(client.FromEnv)
+		c, err = client.NewClientWithOpts(append([]client.Opt{client.FromEnv}, clientOpts...)...)
 		if err != nil {
 			return nil, err
 		}
@@ -826,7 +1458,7 @@ func NewDockerProvider(provOpts ...DockerProviderOption) (*DockerProvider, error
 	p := &DockerProvider{
 		DockerProviderOptions: o,
 		host:                  host,
-		client:                c,
+		client:                withOperationTimeout(c, o.operationTimeout),
 		config:                tcConfig,
 	}
 
@@ -849,6 +1481,11 @@ func configureTC() TestContainersConfig {
 			config.RyukPrivileged = ryukPrivilegedEnv == "true"
 		}
 
+		ryukDisabledEnv := os.Getenv("TESTCONTAINERS_RYUK_DISABLED")
+		if ryukDisabledEnv != "" {
+			config.RyukDisabled = ryukDisabledEnv == "true"
+		}
+
 		return config
 	}
 
@@ -892,34 +1529,200 @@ func (p *DockerProvider) BuildImage(ctx context.Context, img ImageBuildInfo) (st
 		Tags:        []string{repoTag},
 		Remove:      true,
 		ForceRemove: true,
+		NoCache:     !img.ShouldUseBuildCache(),
+		PullParent:  img.ShouldPullParent(),
 	}
 
-	resp, err := p.client.ImageBuild(ctx, buildContext, buildOptions)
-	if err != nil {
-		return "", err
+	// the secrets are served to the BuildKit builder through a session, so that they are not stored in the image
+	var secrets map[string]string
+	if s, ok := img.(ImageBuildSecrets); ok {
+		secrets = s.GetBuildSecrets()
 	}
+	if len(secrets) > 0 {
+		if err := p.checkBuildKit(ctx); err != nil {
+			return "", err
+		}
 
-	if img.ShouldPrintBuildLog() {
-		termFd, isTerm := term.GetFdInfo(os.Stderr)
-		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stderr, termFd, isTerm, nil)
+		sessionID, stop, err := p.secretsSession(ctx, secrets)
 		if err != nil {
 			return "", err
 		}
+		defer stop()
+
+		buildOptions.Version = types.BuilderBuildKit
+		buildOptions.SessionID = sessionID
 	}
 
-	// need to read the response from Docker, I think otherwise the image
-	// might not finish building before continuing to execute here
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
+	resp, err := p.client.ImageBuild(ctx, buildContext, buildOptions)
 	if err != nil {
+		// e.g. a Windows daemon or Podman, which build without BuildKit
+		if len(secrets) > 0 && strings.Contains(strings.ToLower(err.Error()), "buildkit") {
+			return "", fmt.Errorf("%w: the build secrets need a BuildKit capable Docker daemon: %v", ErrBuildKitNotAvailable, err)
+		}
 		return "", err
 	}
 
-	_ = resp.Body.Close()
+	defer resp.Body.Close()
+
+	// the output must be read until the end, otherwise the image might not finish building
+	// before continuing to execute here. It is decoded, so that the errors of the build are reported
+	output := img.GetBuildLogWriter()
+	termFd, isTerm := term.GetFdInfo(output)
+	err = jsonmessage.DisplayJSONMessagesStream(resp.Body, output, termFd, isTerm, nil)
+	if err != nil {
+		return "", err
+	}
 
 	return repoTag, nil
 }
 
+// checkBuildKit checks that the Docker daemon is able to build with BuildKit: either it is its default builder,
+// or the daemon is a Linux one supporting the API version 1.39, which can be asked for a BuildKit build.
+// The negotiated version of the client is not enough, as it can be pinned to a version the daemon does not support
+func (p *DockerProvider) checkBuildKit(ctx context.Context) error {
+	ping, err := p.client.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check the BuildKit support of the Docker daemon: %w", err)
+	}
+
+	if ping.BuilderVersion == types.BuilderBuildKit {
+		return nil
+	}
+	if ping.OSType == "windows" {
+		return fmt.Errorf("%w: the build secrets need a Linux Docker daemon", ErrBuildKitNotAvailable)
+	}
+	if versions.LessThan(ping.APIVersion, "1.39") {
+		return fmt.Errorf("%w: the build secrets need the API version 1.39, the Docker daemon supports %s", ErrBuildKitNotAvailable, ping.APIVersion)
+	}
+
+	return nil
+}
+
+// secretsSession starts a BuildKit session serving the given secrets to the builder of the Docker daemon,
+// which reaches it through the session endpoint of the Docker API. It returns the ID of the session,
+// and the function ending it
+func (p *DockerProvider) secretsSession(ctx context.Context, secrets map[string]string) (string, func(), error) {
+	s, err := session.NewSession(ctx, "testcontainers", "")
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot create the BuildKit session: %w", err)
+	}
+
+	contents := make(map[string][]byte, len(secrets))
+	for id, content := range secrets {
+		contents[id] = []byte(content)
+	}
+	s.Allow(secretsprovider.FromMap(contents))
+
+	// the session is ended by cancelling its context rather than closing it,
+	// as closing a session which is not running yet does not end it
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dialer := func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
+			return p.client.DialHijack(ctx, "/session", proto, meta)
+		}
+		if err := s.Run(ctx, dialer); err != nil {
+			p.Logger.Printf("BuildKit session %s ended: %v", s.ID(), err)
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+	}
+
+	return s.ID(), stop, nil
+}
+
+// SaveImage exports the image, with its tags, to a tar archive at tarPath, which can be loaded later with LoadImage,
+// e.g. to run the tests without access to the registry, using the PullNever policy
+func (p *DockerProvider) SaveImage(ctx context.Context, imageRef string, tarPath string) error {
+	reader, err := p.client.ImageSave(ctx, []string{imageRef})
+	if err != nil {
+		return fmt.Errorf("error saving image %s: %w", imageRef, err)
+	}
+	defer reader.Close()
+
+	f, err := os.Create(tarPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, reader); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing image %s to %s: %w", imageRef, tarPath, err)
+	}
+
+	return f.Close()
+}
+
+// LoadImage loads the images of a tar archive, such as the ones written by SaveImage or docker save, into the daemon.
+// It returns the references of the loaded images: their tags, or their IDs for the untagged ones
+func (p *DockerProvider) LoadImage(ctx context.Context, tarPath string) ([]string, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	resp, err := p.client.ImageLoad(ctx, f, true)
+	if err != nil {
+		return nil, fmt.Errorf("error loading images from %s: %w", tarPath, err)
+	}
+	defer resp.Body.Close()
+
+	// the references are only reported in the messages of the output, e.g. "Loaded image: nginx:alpine"
+	var refs []string
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error reading the output of the load of %s: %w", tarPath, err)
+		}
+		if msg.Error != nil {
+			return nil, fmt.Errorf("error loading images from %s: %w", tarPath, msg.Error)
+		}
+
+		for _, prefix := range []string{"Loaded image: ", "Loaded image ID: "} {
+			if strings.HasPrefix(msg.Stream, prefix) {
+				refs = append(refs, strings.TrimSpace(strings.TrimPrefix(msg.Stream, prefix)))
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// endpointIPAMConfig returns the endpoint configuration assigning the given static IPv4 address,
+// which must be in one of the subnets of the network. There is no configuration if the address is empty
+func endpointIPAMConfig(nw types.NetworkResource, address string) (*network.EndpointIPAMConfig, error) {
+	if address == "" {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(address)
+	for _, config := range nw.IPAM.Config {
+		_, subnet, err := net.ParseCIDR(config.Subnet)
+		if err == nil && subnet.Contains(ip) {
+			return &network.EndpointIPAMConfig{IPv4Address: address}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is not in the subnet of network %s", ErrInvalidIPAddress, address, nw.Name)
+}
+
+// waitStrategy returns the wait strategy of the request, or the default one of the provider when the request has none
+func (p *DockerProvider) waitStrategy(req ContainerRequest) wait.Strategy {
+	if req.WaitingFor != nil {
+		return req.WaitingFor
+	}
+	return p.DefaultWaitStrategy
+}
+
 // CreateContainer fulfills a request for a container without starting it
 func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerRequest) (Container, error) {
 	var err error
@@ -959,6 +1762,21 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		req.Labels = make(map[string]string)
 	}
 
+	if len(p.DefaultLabels) > 0 {
+		// the labels of the request are copied, so that the caller's map is not modified
+		labels := make(map[string]string, len(p.DefaultLabels)+len(req.Labels))
+		for k, v := range p.DefaultLabels {
+			if strings.HasPrefix(k, TestcontainerLabel) {
+				return nil, fmt.Errorf("%w: default label %s", ErrReservedLabel, k)
+			}
+			labels[k] = v
+		}
+		for k, v := range req.Labels {
+			labels[k] = v
+		}
+		req.Labels = labels
+	}
+
 	sessionID := sessionID()
 
 	reaperOpts := containerOptions{
@@ -976,13 +1794,19 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		if err != nil {
 			return nil, fmt.Errorf("%w: creating reaper failed", err)
 		}
-		termSignal, err = r.Connect()
-		if err != nil {
-			return nil, fmt.Errorf("%w: connecting to reaper failed", err)
+		// a reused container is labelled with the hash of its request instead, so that it outlives the session.
+		// The labels are checked before connecting to the reaper, so that a rejected request does not leak the connection
+		if _, reused := req.Labels[TestcontainerLabelHash]; !reused {
+			if err := req.WithSessionLabels(r.SessionID); err != nil {
+				return nil, err
+			}
 		}
-		for k, v := range r.Labels() {
-			if _, ok := req.Labels[k]; !ok {
-				req.Labels[k] = v
+		if r.disabled {
+			p.printReaperBanner("container")
+		} else {
+			termSignal, err = r.Connect()
+			if err != nil {
+				return nil, fmt.Errorf("%w: connecting to reaper failed", err)
 			}
 		}
 	} else if !isReaperContainer {
@@ -1012,22 +1836,9 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 			platform = &p
 		}
 
-		var shouldPullImage bool
-
-		if req.AlwaysPullImage {
-			shouldPullImage = true // If requested always attempt to pull image
-		} else {
-			image, _, err := p.client.ImageInspectWithRaw(ctx, tag)
-			if err != nil {
-				if client.IsErrNotFound(err) {
-					shouldPullImage = true
-				} else {
-					return nil, err
-				}
-			}
-			if platform != nil && (image.Architecture != platform.Architecture || image.Os != platform.OS) {
-				shouldPullImage = true
-			}
+		shouldPullImage, err := p.shouldPullImage(ctx, req, tag, platform)
+		if err != nil {
+			return nil, err
 		}
 
 		if shouldPullImage {
@@ -1039,7 +1850,13 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 				pullOpt.RegistryAuth = req.RegistryCred
 			}
 
-			if err := p.attemptToPullImage(ctx, tag, pullOpt); err != nil {
+			if err := p.attemptToPullImage(ctx, tag, pullOpt, req.ImagePullRetries); err != nil {
+				return nil, err
+			}
+		}
+
+		if req.VerifyDigest {
+			if err := p.verifyImageDigest(ctx, tag); err != nil {
 				return nil, err
 			}
 		}
@@ -1060,6 +1877,10 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 	if err != nil {
 		return nil, err
 	}
+	// the ports of a container in the host network mode are the ones of the host, so they cannot be published
+	if req.NetworkMode.IsHost() {
+		exposedPortMap = nil
+	}
 
 	dockerInput := &container.Config{
 		Entrypoint:   req.Entrypoint,
@@ -1070,43 +1891,71 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		Cmd:          req.Cmd,
 		Hostname:     req.Hostname,
 		User:         req.User,
+		WorkingDir:   req.WorkingDir,
+		StopSignal:   req.StopSignal,
 	}
 
 	// prepare mounts
-	mounts := mapToDockerMounts(req.Mounts)
+	mounts := mapToDockerMounts(req.Mounts, sessionLabels(sessionID.String()))
 
 	hostConfig := &container.HostConfig{
-		ExtraHosts:   req.ExtraHosts,
-		PortBindings: exposedPortMap,
-		Binds:        req.Binds,
-		Mounts:       mounts,
-		Tmpfs:        req.Tmpfs,
-		AutoRemove:   req.AutoRemove,
-		Privileged:   req.Privileged,
-		NetworkMode:  req.NetworkMode,
-		Resources:    req.Resources,
-		ShmSize:      req.ShmSize,
-		CapAdd:       req.CapAdd,
-		CapDrop:      req.CapDrop,
+		ExtraHosts:     req.ExtraHosts,
+		PortBindings:   exposedPortMap,
+		Binds:          req.Binds,
+		Mounts:         mounts,
+		Tmpfs:          req.Tmpfs,
+		ReadonlyRootfs: req.ReadOnlyRootfs,
+		AutoRemove:     req.AutoRemove,
+		Privileged:     req.Privileged,
+		NetworkMode:    req.NetworkMode,
+		Resources:      req.Resources,
+		RestartPolicy:  req.RestartPolicy,
+		ShmSize:        req.ShmSize,
+		CapAdd:         req.CapAdd,
+		CapDrop:        req.CapDrop,
+	}
+
+	if req.GPUs != nil {
+		// the device requests were introduced in the version 1.40 of the Docker API
+		if version := p.client.ClientVersion(); versions.LessThan(version, "1.40") {
+			return nil, fmt.Errorf("%w: the version %s of the Docker API does not support device requests, 1.40 is needed", ErrGPUsNotAvailable, version)
+		}
+		// the device requests of the resources are copied, so that the caller's slice is not modified
+		deviceRequests := make([]container.DeviceRequest, 0, len(req.Resources.DeviceRequests)+1)
+		deviceRequests = append(deviceRequests, req.Resources.DeviceRequests...)
+		hostConfig.DeviceRequests = append(deviceRequests, req.GPUs.deviceRequest())
 	}
 
 	endpointConfigs := map[string]*network.EndpointSettings{}
+	var otherEndpoints []*network.EndpointSettings
 
 	// #248: Docker allows only one network to be specified during container creation
 	// If there is more than one network specified in the request container should be attached to them
-	// once it is created. We will take a first network if any specified in the request and use it to create container
-	if len(req.Networks) > 0 {
-		attachContainerTo := req.Networks[0]
-
+	// once it is created. We will take a first network if any specified in the request and use it to create container.
+	// The endpoints of all the networks are resolved beforehand, so that an invalid static address does not leave
+	// a created container behind
+	for i, n := range req.Networks {
 		nw, err := p.GetNetwork(ctx, NetworkRequest{
-			Name: attachContainerTo,
+			Name: n,
 		})
-		if err == nil {
-			endpointSetting := network.EndpointSettings{
-				Aliases:   req.NetworkAliases[attachContainerTo],
-				NetworkID: nw.ID,
-			}
-			endpointConfigs[attachContainerTo] = &endpointSetting
+		if err != nil {
+			continue
+		}
+
+		ipamConfig, err := endpointIPAMConfig(nw, req.IPv4Addresses[n])
+		if err != nil {
+			return nil, err
+		}
+
+		endpointSetting := &network.EndpointSettings{
+			Aliases:    req.NetworkAliases[n],
+			NetworkID:  nw.ID,
+			IPAMConfig: ipamConfig,
+		}
+		if i == 0 {
+			endpointConfigs[n] = endpointSetting
+		} else {
+			otherEndpoints = append(otherEndpoints, endpointSetting)
 		}
 	}
 
@@ -1120,26 +1969,16 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 	}
 
 	// #248: If there is more than one network specified in the request attach newly created container to them one by one
-	if len(req.Networks) > 1 {
-		for _, n := range req.Networks[1:] {
-			nw, err := p.GetNetwork(ctx, NetworkRequest{
-				Name: n,
-			})
-			if err == nil {
-				endpointSetting := network.EndpointSettings{
-					Aliases: req.NetworkAliases[n],
-				}
-				err = p.client.NetworkConnect(ctx, nw.ID, resp.ID, &endpointSetting)
-				if err != nil {
-					return nil, err
-				}
-			}
+	for _, endpointSetting := range otherEndpoints {
+		err = p.client.NetworkConnect(ctx, endpointSetting.NetworkID, resp.ID, endpointSetting)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	c := &DockerContainer{
 		ID:                resp.ID,
-		WaitingFor:        req.WaitingFor,
+		WaitingFor:        p.waitStrategy(req),
 		Image:             tag,
 		imageWasBuilt:     req.ShouldBuildImage(),
 		sessionID:         sessionID,
@@ -1148,15 +1987,36 @@ func (p *DockerProvider) CreateContainer(ctx context.Context, req ContainerReque
 		skipReaper:        req.SkipReaper,
 		stopProducer:      make(chan bool),
 		logger:            p.Logger,
+		lifecycleHooks:    req.LifecycleHooks,
+		logDumpDir:        req.LogDumpDir,
+		stopSignal:        req.StopSignal,
+		startupCommand:    req.StartupCommand,
 	}
 
 	for _, f := range req.Files {
+		if f.Reader != nil {
+			content, err := io.ReadAll(f.Reader)
+			if err != nil {
+				return nil, fmt.Errorf("can't read the content of %s: %w", f.ContainerFilePath, err)
+			}
+			if err := c.CopyToContainer(ctx, content, f.ContainerFilePath, f.FileMode); err != nil {
+				return nil, fmt.Errorf("can't copy the content of %s to container: %w", f.ContainerFilePath, err)
+			}
+			continue
+		}
+
 		err := c.CopyFileToContainer(ctx, f.HostFilePath, f.ContainerFilePath, f.FileMode)
 		if err != nil {
 			return nil, fmt.Errorf("can't copy %s to container: %w", f.HostFilePath, err)
 		}
 	}
 
+	if len(req.Secrets) > 0 {
+		if err := c.copySecrets(ctx, req.Secrets); err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -1177,12 +2037,102 @@ func (p *DockerProvider) findContainerByName(ctx context.Context, name string) (
 	return nil, nil
 }
 
+// ListSessionContainers returns the containers labelled with the given session ID, including the stopped ones,
+// e.g. to get the logs of the containers created by a test harness. They are not reaped by this provider
+func (p *DockerProvider) ListSessionContainers(ctx context.Context, sessionID string) ([]Container, error) {
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: labelFilters(sessionLabels(sessionID)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the session ID is kept as is when it is not a UUID, as sessions are only matched by their label
+	id, _ := uuid.Parse(sessionID)
+
+	handles := make([]Container, 0, len(containers))
+	for _, c := range containers {
+		handles = append(handles, &DockerContainer{
+			ID:           c.ID,
+			Image:        c.Image,
+			sessionID:    id,
+			provider:     p,
+			skipReaper:   true,
+			stopProducer: make(chan bool),
+			logger:       p.Logger,
+			isRunning:    c.State == "running",
+		})
+	}
+
+	return handles, nil
+}
+
+// ContainerFromID returns a handle of an existing container, e.g. started outside of Testcontainers, from its ID or
+// its name. Its session is read from its labels, if any. The container is not reaped by this provider
+func (p *DockerProvider) ContainerFromID(ctx context.Context, id string) (Container, error) {
+	inspect, raw, err := p.client.ContainerInspectWithRaw(ctx, id, false)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, errdefs.NotFound(fmt.Errorf("container %s does not exist: %w", id, err))
+		}
+		return nil, err
+	}
+
+	// the session ID is left empty when the container has no session label
+	sessionID, _ := uuid.Parse(inspect.Config.Labels[TestcontainerLabelSessionID])
+
+	c := &DockerContainer{
+		ID:           inspect.ID,
+		Image:        inspect.Config.Image,
+		sessionID:    sessionID,
+		provider:     p,
+		skipReaper:   true,
+		stopProducer: make(chan bool),
+		logger:       p.Logger,
+		isRunning:    inspect.State != nil && inspect.State.Running,
+		raw:          &inspect,
+		inspectCache: raw,
+		inspectedAt:  time.Now(),
+	}
+
+	return c, nil
+}
+
+// ReuseOrCreateContainer reuses the running container with the name of the request, if it was created from the same request,
+// or without Reuse. Otherwise, the container with that name is removed and a new one is created: it is not removed
+// by the reaper at the end of the session, so that it can be reused by the next runs
 func (p *DockerProvider) ReuseOrCreateContainer(ctx context.Context, req ContainerRequest) (Container, error) {
+	hash, err := req.hash()
+	if err != nil {
+		return nil, err
+	}
+
 	c, err := p.findContainerByName(ctx, req.Name)
 	if err != nil {
 		return nil, err
 	}
+	if c != nil {
+		// a container created without Reuse has no hash, it is reused as is
+		previousHash, ok := c.Labels[TestcontainerLabelHash]
+		if ok && previousHash != hash {
+			p.Logger.Printf("Removing container %s, which was created from another request", req.Name)
+			err := p.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{RemoveVolumes: true, Force: true})
+			if err != nil {
+				return nil, err
+			}
+			c = nil
+		}
+	}
 	if c == nil {
+		// the labels of the request are copied, so that the caller's map is not modified
+		labels := make(map[string]string, len(req.Labels)+1)
+		for k, v := range req.Labels {
+			labels[k] = v
+		}
+		labels[TestcontainerLabelHash] = hash
+		req.Labels = labels
+
 		return p.CreateContainer(ctx, req)
 	}
 
@@ -1193,16 +2143,20 @@ func (p *DockerProvider) ReuseOrCreateContainer(ctx context.Context, req Contain
 		if err != nil {
 			return nil, fmt.Errorf("%w: creating reaper failed", err)
 		}
-		termSignal, err = r.Connect()
-		if err != nil {
-			return nil, fmt.Errorf("%w: connecting to reaper failed", err)
+		if r.disabled {
+			p.printReaperBanner("container")
+		} else {
+			termSignal, err = r.Connect()
+			if err != nil {
+				return nil, fmt.Errorf("%w: connecting to reaper failed", err)
+			}
 		}
 	} else {
 		p.printReaperBanner("container")
 	}
 	dc := &DockerContainer{
 		ID:                c.ID,
-		WaitingFor:        req.WaitingFor,
+		WaitingFor:        p.waitStrategy(req),
 		Image:             c.Image,
 		sessionID:         sessionID,
 		provider:          p,
@@ -1210,38 +2164,192 @@ func (p *DockerProvider) ReuseOrCreateContainer(ctx context.Context, req Contain
 		skipReaper:        req.SkipReaper,
 		stopProducer:      make(chan bool),
 		logger:            p.Logger,
+		lifecycleHooks:    req.LifecycleHooks,
+		logDumpDir:        req.LogDumpDir,
+		stopSignal:        req.StopSignal,
+		startupCommand:    req.StartupCommand,
 		isRunning:         c.State == "running",
 	}
 
 	return dc, nil
 }
 
-// attemptToPullImage tries to pull the image while respecting the ctx cancellations.
-// Besides, if the image cannot be pulled due to ErrorNotFound then no need to retry but terminate immediately.
-func (p *DockerProvider) attemptToPullImage(ctx context.Context, tag string, pullOpt types.ImagePullOptions) error {
-	var (
-		err  error
-		pull io.ReadCloser
-	)
-	err = backoff.Retry(func() error {
-		pull, err = p.client.ImagePull(ctx, tag, pullOpt)
+// shouldPullImage returns whether the image of the request must be pulled according to its pull policy:
+// PullNever fails if the image is not present locally, or if its platform is not the requested one
+func (p *DockerProvider) shouldPullImage(ctx context.Context, req ContainerRequest, tag string, platform *specs.Platform) (bool, error) {
+	if req.AlwaysPullImage || req.PullPolicy == PullAlways {
+		return true, nil
+	}
+
+	image, _, err := p.client.ImageInspectWithRaw(ctx, tag)
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			return false, err
+		}
+		if req.PullPolicy == PullNever {
+			return false, fmt.Errorf("%w: %s, and the pull policy is PullNever", ErrImageNotPresent, tag)
+		}
+		return true, nil
+	}
+
+	if platform != nil && (image.Architecture != platform.Architecture || image.Os != platform.OS) {
+		if req.PullPolicy == PullNever {
+			return false, fmt.Errorf("%w: %s for platform %s, and the pull policy is PullNever", ErrImageNotPresent, tag, req.ImagePlatform)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// verifyImageDigest checks that the local image has the digest pinned in its reference, e.g. to detect a local image
+// overwritten with another content. An ErrImageDigestMismatch error is returned when none of its repository digests
+// is the pinned one
+func (p *DockerProvider) verifyImageDigest(ctx context.Context, image string) error {
+	pinned, err := imageDigest(image)
+	if err != nil {
+		return err
+	}
+
+	inspect, _, err := p.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	for _, repoDigest := range inspect.RepoDigests {
+		local, err := imageDigest(repoDigest)
 		if err != nil {
-			if _, ok := err.(errdefs.ErrNotFound); ok {
-				return backoff.Permanent(err)
-			}
-			Logger.Printf("Failed to pull image: %s, will retry", err)
-			return err
+			continue
 		}
-		return nil
-	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+		if local.Name() == pinned.Name() && local.Digest() == pinned.Digest() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s is pinned, the local image %s has the digests %v",
+		ErrImageDigestMismatch, image, inspect.ID, inspect.RepoDigests)
+}
+
+// defaultImagePullRetries is the number of retries of a failed pull, i.e. an image is pulled up to 3 times
+const defaultImagePullRetries = 2
+
+// maxImagePullRetryAfter bounds the delay asked by a rate-limited registry, so that a long rate limit
+// fails the pull instead of hanging the tests
+const maxImagePullRetryAfter = time.Minute
+
+// attemptToPullImage tries to pull the image while respecting the ctx cancellations.
+// The pull is retried with an exponential backoff on transient errors only, e.g. network errors or 5xx and 429 responses
+// of the registry, up to the given number of retries: an image which is not found or not authorized fails immediately.
+// The errors reported in the pull stream are retried alike, and the delay asked by a rate-limited registry is honored
+func (p *DockerProvider) attemptToPullImage(ctx context.Context, tag string, pullOpt types.ImagePullOptions, retries int) error {
+	if retries == 0 {
+		retries = defaultImagePullRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	b := &retryAfterBackOff{BackOff: backoff.WithMaxRetries(backoff.NewExponentialBackOff(), uint64(retries))}
+	err := backoff.Retry(func() error {
+		err := p.pullImage(ctx, tag, pullOpt)
+		if err == nil {
+			return nil
+		}
+		if !isTransientPullError(err) {
+			return backoff.Permanent(err)
+		}
+		b.retryAfter = pullRetryAfter(err)
+		Logger.Printf("Failed to pull image: %s, will retry", err)
+		return err
+	}, backoff.WithContext(b, ctx))
+	if err != nil && pullOpt.Platform != "" {
+		return fmt.Errorf("failed to pull image %s for platform %s: %w", tag, pullOpt.Platform, err)
+	}
+	return err
+}
+
+// pullImage pulls the image once
+func (p *DockerProvider) pullImage(ctx context.Context, tag string, pullOpt types.ImagePullOptions) error {
+	pull, err := p.client.ImagePull(ctx, tag, pullOpt)
 	if err != nil {
 		return err
 	}
 	defer pull.Close()
 
-	// download of docker image finishes at EOF of the pull request
-	_, err = io.ReadAll(pull)
-	return err
+	// download of docker image finishes at EOF of the pull request, the errors are reported in the stream,
+	// e.g. when the image is not available for the requested platform, or when the registry rate limits the pull
+	return jsonmessage.DisplayJSONMessagesStream(pull, io.Discard, 0, false, nil)
+}
+
+// isTransientPullError returns whether a failed pull may succeed when retried. The Docker daemon relays the rate limits
+// of the registries as errors containing toomanyrequests. The errors of the pull stream are only transient when their
+// code, if any, is a 429 or 5xx status, or when they report a rate limit or a network error
+func isTransientPullError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if strings.Contains(err.Error(), "toomanyrequests") {
+		return true
+	}
+
+	var streamErr *jsonmessage.JSONError
+	if errors.As(err, &streamErr) {
+		if streamErr.Code != 0 {
+			return streamErr.Code == http.StatusTooManyRequests || streamErr.Code >= http.StatusInternalServerError
+		}
+
+		msg := strings.ToLower(streamErr.Message)
+		for _, transient := range []string{"too many requests", "connection reset", "timeout", "unexpected eof", "status: 5"} {
+			if strings.Contains(msg, transient) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return !(errdefs.IsNotFound(err) || errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) ||
+		errdefs.IsInvalidParameter(err) || errdefs.IsNotImplemented(err))
+}
+
+// retryAfterPattern matches the Retry-After header of a rate-limited registry, in seconds, when it is relayed in the error
+var retryAfterPattern = regexp.MustCompile(`(?i)retry-after:?\s*(\d+)`)
+
+// pullRetryAfter returns the delay asked by a rate-limited registry before retrying the pull, bounded by
+// maxImagePullRetryAfter, or zero when there is none
+func pullRetryAfter(err error) time.Duration {
+	match := retryAfterPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+
+	seconds, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return maxImagePullRetryAfter
+	}
+
+	retryAfter := time.Duration(seconds) * time.Second
+	if retryAfter > maxImagePullRetryAfter {
+		return maxImagePullRetryAfter
+	}
+	return retryAfter
+}
+
+// retryAfterBackOff waits for the delay asked by a rate-limited registry instead of the backoff delay,
+// when the last error asked for one
+type retryAfterBackOff struct {
+	backoff.BackOff
+	retryAfter time.Duration
+}
+
+func (b *retryAfterBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop || b.retryAfter <= 0 {
+		return next
+	}
+
+	retryAfter := b.retryAfter
+	b.retryAfter = 0
+	return retryAfter
 }
 
 // Health measure the healthiness of the provider. Right now we leverage the
@@ -1271,10 +2379,13 @@ func (p *DockerProvider) Config() TestContainersConfig {
 	return p.config
 }
 
-// daemonHost gets the host or ip of the Docker daemon where ports are exposed on
+// DaemonHost gets the host or ip of the Docker daemon where ports are exposed on, e.g. to build external URLs:
+// the host of a tcp:// Docker host, or localhost for a unix socket, as Docker Desktop uses,
+// unless running in a container, where the gateway is used.
+// The result is cached, as it does not change for a provider.
 // Warning: this is based on your Docker host setting. Will fail if using an SSH tunnel
 // You can use the "TC_HOST" env variable to set this yourself
-func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
+func (p *DockerProvider) DaemonHost(ctx context.Context) (string, error) {
 	if p.hostCache != "" {
 		return p.hostCache, nil
 	}
@@ -1317,7 +2428,10 @@ func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 
 // CreateNetwork returns the object representing a new network identified by its name
 func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest) (Network, error) {
-	var err error
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
 
 	// Make sure that bridge network exists
 	// In case it is disabled we will create reaper_default network
@@ -1327,19 +2441,19 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 		}
 	}
 
-	if req.Labels == nil {
-		req.Labels = make(map[string]string)
+	// the networks are labelled like the containers: with the default labels of the provider, which the labels
+	// of the request take precedence over, and with the session labels. The caller's map is not modified
+	labels := make(map[string]string, len(p.DefaultLabels)+len(req.Labels))
+	for k, v := range p.DefaultLabels {
+		if strings.HasPrefix(k, TestcontainerLabel) {
+			return nil, fmt.Errorf("%w: default label %s", ErrReservedLabel, k)
+		}
+		labels[k] = v
 	}
-
-	nc := types.NetworkCreate{
-		Driver:         req.Driver,
-		CheckDuplicate: req.CheckDuplicate,
-		Internal:       req.Internal,
-		EnableIPv6:     req.EnableIPv6,
-		Attachable:     req.Attachable,
-		Labels:         req.Labels,
-		IPAM:           req.IPAM,
+	for k, v := range req.Labels {
+		labels[k] = v
 	}
+	req.Labels = labels
 
 	var termSignal chan bool
 	if !req.SkipReaper {
@@ -1348,19 +2462,35 @@ func (p *DockerProvider) CreateNetwork(ctx context.Context, req NetworkRequest)
 		if err != nil {
 			return nil, fmt.Errorf("%w: creating network reaper failed", err)
 		}
-		termSignal, err = r.Connect()
-		if err != nil {
-			return nil, fmt.Errorf("%w: connecting to network reaper failed", err)
-		}
+		// the labels are checked before connecting to the reaper, so that a rejected request does not leak the connection
 		for k, v := range r.Labels() {
-			if _, ok := req.Labels[k]; !ok {
-				req.Labels[k] = v
+			if current, ok := req.Labels[k]; ok && current != v {
+				return nil, fmt.Errorf("%w: %s=%s conflicts with %s", ErrReservedLabel, k, current, v)
+			}
+			req.Labels[k] = v
+		}
+		if r.disabled {
+			p.printReaperBanner("network")
+		} else {
+			termSignal, err = r.Connect()
+			if err != nil {
+				return nil, fmt.Errorf("%w: connecting to network reaper failed", err)
 			}
 		}
 	} else {
 		p.printReaperBanner("network")
 	}
 
+	nc := types.NetworkCreate{
+		Driver:         req.Driver,
+		CheckDuplicate: req.CheckDuplicate,
+		Internal:       req.Internal,
+		EnableIPv6:     req.EnableIPv6,
+		Attachable:     req.Attachable,
+		Labels:         req.Labels,
+		IPAM:           req.IPAM,
+	}
+
 	response, err := p.client.NetworkCreate(ctx, req.Name, nc)
 	if err != nil {
 		return &DockerNetwork{}, err
@@ -1417,6 +2547,17 @@ func (p *DockerProvider) GetGatewayIP(ctx context.Context) (string, error) {
 	return ip, nil
 }
 
+// SessionReaper returns the reaper of the current test session, starting it if needed, e.g. to connect to it
+// on behalf of resources which are not created by the provider, such as the ones of a compose stack.
+// The resources are only removed by the reaper once all the connections opened with Reaper.Connect are terminated
+func (p *DockerProvider) SessionReaper(ctx context.Context, opts ...ContainerOption) (*Reaper, error) {
+	r, err := newReaper(context.WithValue(ctx, dockerHostContextKey, p.host), sessionID().String(), p, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating reaper failed", err)
+	}
+	return r, nil
+}
+
 func (p *DockerProvider) printReaperBanner(resource string) {
 	ryukDisabledMessage := `
 	**********************************************************************************************
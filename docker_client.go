@@ -0,0 +1,156 @@
+package testcontainers
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// timeoutClient is a Docker client bounding each call to the daemon by a timeout, so that a wedged daemon
+// fails the call instead of hanging it. Only the calls replying at once are bounded: the streaming calls,
+// such as pulling, building, saving or loading an image, copying files or following logs, whose body is read
+// once the call returns and would be cut by the timeout, and the calls bounded by their own timeout, such as
+// stopping a container, are left to the context of the caller
+type timeoutClient struct {
+	client.APIClient
+	timeout time.Duration
+}
+
+// withOperationTimeout returns the client bounding each call by the timeout, or the client itself
+// when the timeout is not positive
+func withOperationTimeout(cli client.APIClient, timeout time.Duration) client.APIClient {
+	if timeout <= 0 {
+		return cli
+	}
+	if tc, ok := cli.(*timeoutClient); ok {
+		cli = tc.APIClient
+	}
+	return &timeoutClient{APIClient: cli, timeout: timeout}
+}
+
+func (c *timeoutClient) ContainerCommit(ctx context.Context, container string, options types.ContainerCommitOptions) (types.IDResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerCommit(ctx, container, options)
+}
+
+func (c *timeoutClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
+}
+
+func (c *timeoutClient) ContainerStart(ctx context.Context, container string, options types.ContainerStartOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerStart(ctx, container, options)
+}
+
+func (c *timeoutClient) ContainerInspect(ctx context.Context, container string) (types.ContainerJSON, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerInspect(ctx, container)
+}
+
+func (c *timeoutClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerList(ctx, options)
+}
+
+func (c *timeoutClient) ContainerRemove(ctx context.Context, container string, options types.ContainerRemoveOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerRemove(ctx, container, options)
+}
+
+func (c *timeoutClient) ContainerUpdate(ctx context.Context, container string, updateConfig container.UpdateConfig) (container.ContainerUpdateOKBody, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerUpdate(ctx, container, updateConfig)
+}
+
+func (c *timeoutClient) ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerExecCreate(ctx, container, config)
+}
+
+func (c *timeoutClient) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ContainerExecInspect(ctx, execID)
+}
+
+func (c *timeoutClient) ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ImageInspectWithRaw(ctx, image)
+}
+
+func (c *timeoutClient) ImageRemove(ctx context.Context, image string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.ImageRemove(ctx, image, options)
+}
+
+func (c *timeoutClient) NetworkConnect(ctx context.Context, network, container string, config *network.EndpointSettings) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkConnect(ctx, network, container, config)
+}
+
+func (c *timeoutClient) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkCreate(ctx, name, options)
+}
+
+func (c *timeoutClient) NetworkDisconnect(ctx context.Context, network, container string, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkDisconnect(ctx, network, container, force)
+}
+
+func (c *timeoutClient) NetworkInspect(ctx context.Context, network string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkInspect(ctx, network, options)
+}
+
+func (c *timeoutClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkList(ctx, options)
+}
+
+func (c *timeoutClient) NetworkRemove(ctx context.Context, network string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.NetworkRemove(ctx, network)
+}
+
+func (c *timeoutClient) VolumeList(ctx context.Context, filter filters.Args) (volume.ListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.VolumeList(ctx, filter)
+}
+
+func (c *timeoutClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.VolumeRemove(ctx, volumeID, force)
+}
+
+func (c *timeoutClient) Ping(ctx context.Context) (types.Ping, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.APIClient.Ping(ctx)
+}
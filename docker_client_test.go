@@ -0,0 +1,106 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wedgedClient is a Docker client whose daemon never replies, until the context of the call is done
+type wedgedClient struct {
+	client.APIClient
+	delay time.Duration
+}
+
+func (c *wedgedClient) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(c.delay):
+		return nil
+	}
+}
+
+func (c *wedgedClient) ImageInspectWithRaw(_ context.Context, _ string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{ID: "sha256:0123456789abcdef", ContainerConfig: &container.Config{}}, nil, nil
+}
+
+func (c *wedgedClient) ContainerCreate(ctx context.Context, _ *container.Config, _ *container.HostConfig, _ *network.NetworkingConfig, _ *specs.Platform, _ string) (container.CreateResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return container.CreateResponse{}, err
+	}
+	return container.CreateResponse{ID: "0123456789abcdef0123456789abcdef"}, nil
+}
+
+func (c *wedgedClient) ContainerCommit(ctx context.Context, _ string, _ types.ContainerCommitOptions) (types.IDResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return types.IDResponse{}, err
+	}
+	return types.IDResponse{ID: "sha256:0123456789abcdef"}, nil
+}
+
+func Test_OperationTimeout(t *testing.T) {
+	req := ContainerRequest{
+		Image:        "nginx:1.17.6",
+		ExposedPorts: []string{"80/tcp"},
+		SkipReaper:   true,
+	}
+
+	newProvider := func(opts ...DockerProviderOption) *DockerProvider {
+		o := &DockerProviderOptions{
+			defaultBridgeNetworkName: Bridge,
+			GenericProviderOptions:   &GenericProviderOptions{Logger: TestLogger(t), DefaultNetwork: Bridge},
+		}
+		for _, opt := range opts {
+			opt.ApplyDockerTo(o)
+		}
+
+		p := &DockerProvider{DockerProviderOptions: o}
+		p.SetClient(&wedgedClient{delay: time.Second})
+		return p
+	}
+
+	t.Run("wedged daemon", func(t *testing.T) {
+		p := newProvider(WithOperationTimeout(50 * time.Millisecond))
+
+		start := time.Now()
+		_, err := p.CreateContainer(context.Background(), req)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "the call should time out: %v", err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("wedged commit", func(t *testing.T) {
+		p := newProvider(WithOperationTimeout(50 * time.Millisecond))
+
+		_, err := p.Client().ContainerCommit(context.Background(), "0123456789abcdef", types.ContainerCommitOptions{})
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "the call should time out: %v", err)
+	})
+
+	t.Run("no timeout by default", func(t *testing.T) {
+		p := newProvider()
+		assert.IsType(t, &wedgedClient{}, p.Client())
+
+		_, err := p.CreateContainer(context.Background(), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("timeout of the caller", func(t *testing.T) {
+		p := newProvider(WithOperationTimeout(time.Minute))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := p.CreateContainer(ctx, req)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "the call should time out: %v", err)
+	})
+}
@@ -79,8 +79,9 @@ func (s DockerTmpfsMountSource) GetTmpfsOptions() *mount.TmpfsOptions {
 }
 
 // mapToDockerMounts maps the given []ContainerMount to the corresponding
-// []mount.Mount for further processing
-func mapToDockerMounts(containerMounts ContainerMounts) []mount.Mount {
+// []mount.Mount for further processing. The volumes of the reaped volume mounts
+// are created with the given session labels
+func mapToDockerMounts(containerMounts ContainerMounts, sessionLabels map[string]string) []mount.Mount {
 	mounts := make([]mount.Mount, 0, len(containerMounts))
 
 	for idx := range containerMounts {
@@ -107,6 +108,10 @@ func mapToDockerMounts(containerMounts ContainerMounts) []mount.Mount {
 			containerMount.VolumeOptions = typedMounter.GetVolumeOptions()
 		case TmpfsMounter:
 			containerMount.TmpfsOptions = typedMounter.GetTmpfsOptions()
+		case GenericVolumeMountSource:
+			if typedMounter.Reaped {
+				containerMount.VolumeOptions = &mount.VolumeOptions{Labels: sessionLabels}
+			}
 		}
 
 		mounts = append(mounts, containerMount)
@@ -0,0 +1,242 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiCall is a call made to the Docker API, on a resource such as an image or a container,
+// and the error it is replied with
+type apiCall struct {
+	Method   string
+	Resource string
+	Err      error
+}
+
+// replayClient replays a script of calls to the Docker API, so that the logic of the provider can be tested
+// without a Docker daemon: each call must be the next one of the script, and it is replied with its error, or with
+// a successful response. The calls are recorded, and the unexpected ones fail the test
+type replayClient struct {
+	client.APIClient
+	t tb
+
+	mx       sync.Mutex
+	script   []apiCall
+	recorded []apiCall
+}
+
+// tb is the subset of testing.TB used by the replay client
+type tb interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// newReplayClient returns a client replaying the given script
+func newReplayClient(t tb, script ...apiCall) *replayClient {
+	return &replayClient{t: t, script: script}
+}
+
+// replay records the call, and returns the error of the script for it
+func (c *replayClient) replay(method string, resource string) error {
+	c.t.Helper()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	call := apiCall{Method: method, Resource: resource}
+	if len(c.script) == 0 {
+		c.t.Errorf("unexpected call %s %s, the script is over", method, resource)
+		return fmt.Errorf("unexpected call %s %s", method, resource)
+	}
+
+	next := c.script[0]
+	c.script = c.script[1:]
+	if next.Method != method || (next.Resource != "" && next.Resource != resource) {
+		c.t.Errorf("unexpected call %s %s, expected %s %s", method, resource, next.Method, next.Resource)
+		return fmt.Errorf("unexpected call %s %s", method, resource)
+	}
+
+	call.Err = next.Err
+	c.recorded = append(c.recorded, call)
+	return next.Err
+}
+
+// calls returns the methods of the recorded calls
+func (c *replayClient) calls() []string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	methods := make([]string, 0, len(c.recorded))
+	for _, call := range c.recorded {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+// assertScriptDone checks that all the calls of the script were made
+func (c *replayClient) assertScriptDone(t *testing.T) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	assert.Empty(t, c.script, "the calls of the script should all be made")
+}
+
+func (c *replayClient) NetworkList(_ context.Context, _ types.NetworkListOptions) ([]types.NetworkResource, error) {
+	if err := c.replay("NetworkList", ""); err != nil {
+		return nil, err
+	}
+	return []types.NetworkResource{{Name: Bridge}}, nil
+}
+
+// NetworkInspect replies with a network of the subnet 10.1.2.0/24
+func (c *replayClient) NetworkInspect(_ context.Context, name string, _ types.NetworkInspectOptions) (types.NetworkResource, error) {
+	if err := c.replay("NetworkInspect", name); err != nil {
+		return types.NetworkResource{}, err
+	}
+	return types.NetworkResource{
+		ID:   name + "-id",
+		Name: name,
+		IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.1.2.0/24"}}},
+	}, nil
+}
+
+func (c *replayClient) ImageInspectWithRaw(_ context.Context, image string) (types.ImageInspect, []byte, error) {
+	if err := c.replay("ImageInspectWithRaw", image); err != nil {
+		return types.ImageInspect{}, nil, err
+	}
+	return types.ImageInspect{ID: "sha256:0123456789abcdef", ContainerConfig: &container.Config{}}, nil, nil
+}
+
+func (c *replayClient) ImagePull(_ context.Context, ref string, _ types.ImagePullOptions) (io.ReadCloser, error) {
+	if err := c.replay("ImagePull", ref); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image for ` + ref + `"}`)), nil
+}
+
+func (c *replayClient) ContainerCreate(_ context.Context, config *container.Config, _ *container.HostConfig, _ *network.NetworkingConfig, _ *specs.Platform, _ string) (container.CreateResponse, error) {
+	if err := c.replay("ContainerCreate", config.Image); err != nil {
+		return container.CreateResponse{}, err
+	}
+	return container.CreateResponse{ID: "0123456789abcdef0123456789abcdef"}, nil
+}
+
+func (c *replayClient) ContainerStart(_ context.Context, id string, _ types.ContainerStartOptions) error {
+	return c.replay("ContainerStart", id)
+}
+
+func (c *replayClient) ContainerRemove(_ context.Context, id string, _ types.ContainerRemoveOptions) error {
+	return c.replay("ContainerRemove", id)
+}
+
+// Close is not a call to the Docker API, so it is not part of the script
+func (c *replayClient) Close() error {
+	return nil
+}
+
+// newReplayProvider returns a provider using the replay client, without reaper
+func newReplayProvider(t *testing.T, cli *replayClient) *DockerProvider {
+	return &DockerProvider{
+		client: cli,
+		DockerProviderOptions: &DockerProviderOptions{
+			defaultBridgeNetworkName: Bridge,
+			GenericProviderOptions:   &GenericProviderOptions{Logger: TestLogger(t)},
+		},
+	}
+}
+
+func Test_ReplayRunContainer(t *testing.T) {
+	ctx := context.Background()
+	image := "docker.io/private/app:1.0"
+	req := ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"8080/tcp"},
+		SkipReaper:   true,
+	}
+
+	t.Run("pull failure", func(t *testing.T) {
+		cli := newReplayClient(t,
+			apiCall{Method: "NetworkList"},
+			apiCall{Method: "ImageInspectWithRaw", Resource: image, Err: errdefs.NotFound(errors.New("no such image"))},
+			apiCall{Method: "ImagePull", Resource: image, Err: errdefs.Unauthorized(errors.New("pull access denied"))},
+		)
+
+		_, err := newReplayProvider(t, cli).RunContainer(ctx, req)
+		require.Error(t, err)
+		assert.True(t, errdefs.IsUnauthorized(err), "the error of the pull should be returned: %v", err)
+		// an unauthorized pull is not retried, and no container is created
+		assert.Equal(t, []string{"NetworkList", "ImageInspectWithRaw", "ImagePull"}, cli.calls())
+		cli.assertScriptDone(t)
+	})
+
+	t.Run("pulled image", func(t *testing.T) {
+		cli := newReplayClient(t,
+			apiCall{Method: "NetworkList"},
+			apiCall{Method: "ImageInspectWithRaw", Resource: image, Err: errdefs.NotFound(errors.New("no such image"))},
+			apiCall{Method: "ImagePull", Resource: image},
+			apiCall{Method: "ContainerCreate", Resource: image},
+			apiCall{Method: "ContainerStart", Resource: "0123456789abcdef0123456789abcdef"},
+			apiCall{Method: "ContainerRemove", Resource: "0123456789abcdef0123456789abcdef"},
+		)
+
+		c, err := newReplayProvider(t, cli).RunContainer(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, c.IsRunning())
+		require.NoError(t, c.Terminate(ctx))
+
+		cli.assertScriptDone(t)
+	})
+
+	t.Run("present image", func(t *testing.T) {
+		cli := newReplayClient(t,
+			apiCall{Method: "NetworkList"},
+			apiCall{Method: "ImageInspectWithRaw", Resource: image},
+			apiCall{Method: "ContainerCreate", Resource: image},
+		)
+
+		_, err := newReplayProvider(t, cli).CreateContainer(ctx, req)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"NetworkList", "ImageInspectWithRaw", "ContainerCreate"}, cli.calls())
+		cli.assertScriptDone(t)
+	})
+}
+
+func Test_ReplayCreateContainerWithStaticAddresses(t *testing.T) {
+	ctx := context.Background()
+	image := "docker.io/private/app:1.0"
+
+	// the address on the second network is only checked once the networks are resolved,
+	// which must happen before the container is created so that it is not left behind
+	cli := newReplayClient(t,
+		apiCall{Method: "NetworkList"},
+		apiCall{Method: "ImageInspectWithRaw", Resource: image},
+		apiCall{Method: "NetworkInspect", Resource: "front"},
+		apiCall{Method: "NetworkInspect", Resource: "back"},
+	)
+
+	_, err := newReplayProvider(t, cli).CreateContainer(ctx, ContainerRequest{
+		Image:         image,
+		ExposedPorts:  []string{"8080/tcp"},
+		Networks:      []string{"front", "back"},
+		IPv4Addresses: map[string]string{"front": "10.1.2.3", "back": "10.1.3.3"},
+		SkipReaper:    true,
+	})
+	require.ErrorIs(t, err, ErrInvalidIPAddress)
+
+	assert.NotContains(t, cli.calls(), "ContainerCreate")
+	cli.assertScriptDone(t)
+}
@@ -1,24 +1,31 @@
 package testcontainers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -28,8 +35,11 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
@@ -203,6 +213,72 @@ func TestContainerWithHostNetworkOptions_UseExposePortsFromImageConfigs(t *testi
 	}
 }
 
+func TestContainerWithHostNetworkAndExposedPortWait(t *testing.T) {
+	ctx := context.Background()
+
+	absPath, err := filepath.Abs("./testresources/nginx-highport.conf")
+	require.NoError(t, err)
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			NetworkMode:  "host",
+			ExposedPorts: []string{nginxHighPort},
+			WaitingFor:   wait.ForExposedPort(),
+			Mounts:       Mounts(BindMount(absPath, "/etc/nginx/conf.d/default.conf")),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	// the port of the host is the one of the container
+	endpoint, err := nginxC.Endpoint(ctx, "http")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(endpoint, ":8080"), endpoint)
+
+	resp, err := http.Get(endpoint)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// hostModeInspectClient inspects a container in the host network mode, which exposes the given ports
+type hostModeInspectClient struct {
+	client.APIClient
+	exposedPorts nat.PortSet
+}
+
+func (c *hostModeInspectClient) ContainerInspect(_ context.Context, id string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         id,
+			HostConfig: &container.HostConfig{NetworkMode: "host"},
+		},
+		Config:          &container.Config{ExposedPorts: c.exposedPorts},
+		NetworkSettings: &types.NetworkSettings{},
+	}, nil
+}
+
+func Test_HostNetworkModePorts(t *testing.T) {
+	ctx := context.Background()
+	c := &DockerContainer{
+		ID: "0123456789abcdef",
+		provider: &DockerProvider{client: &hostModeInspectClient{exposedPorts: nat.PortSet{
+			"8080/tcp": struct{}{},
+		}}},
+	}
+
+	ports, err := c.Ports(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, nat.PortMap{"8080/tcp": []nat.PortBinding{{HostPort: "8080"}}}, ports)
+
+	port, err := c.MappedPort(ctx, "8080/tcp")
+	require.NoError(t, err)
+	assert.Equal(t, nat.Port("8080/tcp"), port)
+}
+
 func TestContainerWithNetworkModeAndNetworkTogether(t *testing.T) {
 	ctx := context.Background()
 	gcr := GenericContainerRequest{
@@ -513,6 +589,59 @@ func TestContainerStateAfterTermination(t *testing.T) {
 	})
 }
 
+func TestContainerStateOfExitedContainer(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		Cmd:        []string{"sh", "-c", "exit 3"},
+		WaitingFor: wait.ForExit(),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	// the container exited on its own after being started
+	assert.True(t, container.IsRunning())
+	assert.False(t, IsRunning(ctx, container))
+
+	state, err := container.State(ctx)
+	require.NoError(t, err)
+	assert.False(t, state.Running)
+	assert.Equal(t, 3, state.ExitCode)
+	assert.False(t, state.OOMKilled)
+	assert.NotEmpty(t, state.StartedAt)
+
+	assert.False(t, container.IsRunning(), "IsRunning should be updated from the inspected state")
+}
+
+func TestContainerTerminateWaitingForRemoval(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		Cmd:        []string{"sleep", "10"},
+		Name:       fmt.Sprintf("tc-terminate-%d", time.Now().UnixNano()),
+		AutoRemove: true,
+	}
+
+	for i := 0; i < 2; i++ {
+		container, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType:     providerType,
+			ContainerRequest: req,
+			Started:          true,
+		})
+		require.NoError(t, err, "the container name should be available again")
+
+		// stopping an AutoRemove container makes Docker remove it in the background
+		require.NoError(t, container.Stop(ctx, nil))
+		require.NoError(t, container.Terminate(ctx, WithWaitForRemoval()))
+	}
+}
+
 func TestContainerStopWithReaper(t *testing.T) {
 	ctx := context.Background()
 
@@ -625,6 +754,42 @@ func TestContainerTerminationWithoutReaper(t *testing.T) {
 	}
 }
 
+func TestContainerTerminateTwice(t *testing.T) {
+	ctx := context.Background()
+
+	nginxA, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, nginxA.Terminate(ctx))
+	require.NoError(t, nginxA.Terminate(ctx), "terminating a terminated container should not fail")
+
+	t.Run("container removed by another client", func(t *testing.T) {
+		nginxB, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:        nginxAlpineImage,
+				ExposedPorts: []string{nginxDefaultPort},
+			},
+			Started: true,
+		})
+		require.NoError(t, err)
+
+		provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+		require.NoError(t, err)
+		err = provider.client.ContainerRemove(ctx, nginxB.GetContainerID(), types.ContainerRemoveOptions{Force: true})
+		require.NoError(t, err)
+
+		require.NoError(t, nginxB.Terminate(ctx), "terminating a removed container should not fail")
+	})
+}
+
 func TestContainerTerminationRemovesDockerImage(t *testing.T) {
 	t.Run("if not built from Dockerfile", func(t *testing.T) {
 		ctx := context.Background()
@@ -850,6 +1015,84 @@ func TestContainerIPs(t *testing.T) {
 	}
 }
 
+func TestContainerNetworkIP(t *testing.T) {
+	ctx := context.Background()
+
+	networkName := "network-ip-" + uuid.NewString()
+	newNetwork, err := GenericNetwork(ctx, GenericNetworkRequest{
+		ProviderType: providerType,
+		NetworkRequest: NetworkRequest{
+			Name:           networkName,
+			CheckDuplicate: true,
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, newNetwork.Remove(ctx))
+	})
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			Networks:     []string{"bridge", networkName},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	bridgeIP, err := nginxC.NetworkIP(ctx, "bridge")
+	require.NoError(t, err)
+	networkIP, err := nginxC.NetworkIP(ctx, networkName)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, bridgeIP)
+	assert.NotEmpty(t, networkIP)
+	assert.NotEqual(t, bridgeIP, networkIP)
+
+	ips, err := nginxC.ContainerIPs(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{bridgeIP, networkIP}, ips)
+
+	_, err = nginxC.NetworkIP(ctx, "not-attached")
+	require.ErrorIs(t, err, ErrNetworkNotAttached)
+}
+
+// networksInspectClient inspects a container attached to the given networks
+type networksInspectClient struct {
+	client.APIClient
+	networks map[string]*network.EndpointSettings
+}
+
+func (c *networksInspectClient) ContainerInspect(_ context.Context, id string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: id},
+		NetworkSettings:   &types.NetworkSettings{Networks: c.networks},
+	}, nil
+}
+
+func Test_NetworkIP(t *testing.T) {
+	ctx := context.Background()
+	c := &DockerContainer{
+		ID: "0123456789abcdef",
+		provider: &DockerProvider{client: &networksInspectClient{networks: map[string]*network.EndpointSettings{
+			"bridge":  {IPAddress: "172.17.0.2"},
+			"backend": {IPAddress: "10.1.0.2"},
+		}}},
+	}
+
+	ip, err := c.NetworkIP(ctx, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.0.2", ip)
+
+	_, err = c.NetworkIP(ctx, "frontend")
+	require.ErrorIs(t, err, ErrNetworkNotAttached)
+	assert.EqualError(t, err, "network not attached: container 0123456789ab is not attached to network frontend")
+}
+
 func TestContainerCreationWithName(t *testing.T) {
 	ctx := context.Background()
 
@@ -1256,6 +1499,191 @@ func Test_BuildContainerFromDockerfileWithBuildArgs(t *testing.T) {
 	assert.Equal(t, ba, string(body))
 }
 
+func Test_BuildImageWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	buildImageID := func(noCache bool) string {
+		req := &ContainerRequest{
+			FromDockerfile: FromDockerfile{
+				Context:    "./testresources",
+				Dockerfile: "nocache.Dockerfile",
+				NoCache:    noCache,
+			},
+		}
+
+		tag, err := provider.BuildImage(ctx, req)
+		require.NoError(t, err)
+
+		image, _, err := provider.client.ImageInspectWithRaw(ctx, tag)
+		require.NoError(t, err)
+
+		_, err = provider.client.ImageRemove(ctx, tag, types.ImageRemoveOptions{})
+		require.NoError(t, err)
+
+		return image.ID
+	}
+
+	cached := buildImageID(false)
+	assert.Equal(t, cached, buildImageID(false), "the cached layers should be reused")
+	assert.NotEqual(t, cached, buildImageID(true), "the layers should be built again without cache")
+}
+
+func Test_BuildImageFromLocalBaseImage(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	// the base image only exists locally, under a random tag which is in no registry
+	baseImage, err := provider.BuildImage(ctx, &ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			Context:    "./testresources",
+			Dockerfile: "echo.Dockerfile",
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := provider.client.ImageRemove(ctx, baseImage, types.ImageRemoveOptions{Force: true})
+		require.NoError(t, err)
+	})
+
+	build := func(pullParent bool) (string, error) {
+		return provider.BuildImage(ctx, &ContainerRequest{
+			FromDockerfile: FromDockerfile{
+				Context:    "./testresources",
+				Dockerfile: "localbase.Dockerfile",
+				BuildArgs:  map[string]*string{"BASE_IMAGE": &baseImage},
+				PullParent: pullParent,
+			},
+		})
+	}
+
+	t.Run("the local base image is used by default", func(t *testing.T) {
+		tag, err := build(false)
+		require.NoError(t, err)
+
+		_, err = provider.client.ImageRemove(ctx, tag, types.ImageRemoveOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("the local base image cannot be pulled", func(t *testing.T) {
+		_, err := build(true)
+		require.Error(t, err)
+	})
+}
+
+// buildSecretsClient records the options of the image builds, and accepts the BuildKit sessions
+// of a daemon answering the given ping without serving them
+type buildSecretsClient struct {
+	client.APIClient
+	ping    types.Ping
+	options []types.ImageBuildOptions
+}
+
+func (c *buildSecretsClient) Ping(_ context.Context) (types.Ping, error) {
+	return c.ping, nil
+}
+
+func (c *buildSecretsClient) DialHijack(_ context.Context, _, _ string, _ map[string][]string) (net.Conn, error) {
+	local, _ := net.Pipe()
+	return local, nil
+}
+
+func (c *buildSecretsClient) ImageBuild(_ context.Context, _ io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	c.options = append(c.options, options)
+	return types.ImageBuildResponse{Body: io.NopCloser(strings.NewReader(`{"stream":"built"}` + "\n"))}, nil
+}
+
+func Test_BuildImageWithSecrets(t *testing.T) {
+	req := &ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			Context:    "./testresources",
+			Dockerfile: "secret.Dockerfile",
+			Secrets:    map[string]string{"token": "s3cr3t"},
+		},
+	}
+
+	newProvider := func(t *testing.T, cli client.APIClient) *DockerProvider {
+		return &DockerProvider{
+			client:                cli,
+			DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: TestLogger(t)}},
+		}
+	}
+
+	t.Run("BuildKit build", func(t *testing.T) {
+		cli := &buildSecretsClient{ping: types.Ping{APIVersion: "1.41", OSType: "linux", BuilderVersion: types.BuilderV1}}
+		p := newProvider(t, cli)
+
+		_, err := p.BuildImage(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, cli.options, 1)
+		assert.Equal(t, types.BuilderBuildKit, cli.options[0].Version)
+		assert.NotEmpty(t, cli.options[0].SessionID)
+	})
+
+	t.Run("build without secrets", func(t *testing.T) {
+		cli := &buildSecretsClient{ping: types.Ping{APIVersion: "1.41", OSType: "linux", BuilderVersion: types.BuilderV1}}
+		p := newProvider(t, cli)
+
+		_, err := p.BuildImage(context.Background(), &ContainerRequest{
+			FromDockerfile: FromDockerfile{Context: "./testresources", Dockerfile: "echo.Dockerfile"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, cli.options, 1)
+		assert.Empty(t, cli.options[0].Version, "the default builder of the daemon should be used")
+		assert.Empty(t, cli.options[0].SessionID)
+	})
+
+	t.Run("BuildKit default builder", func(t *testing.T) {
+		cli := &buildSecretsClient{ping: types.Ping{APIVersion: "1.43", OSType: "linux", BuilderVersion: types.BuilderBuildKit}}
+		p := newProvider(t, cli)
+
+		_, err := p.BuildImage(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, cli.options, 1)
+	})
+
+	unavailable := []struct {
+		name string
+		ping types.Ping
+	}{
+		{name: "API version without BuildKit", ping: types.Ping{APIVersion: "1.38", OSType: "linux"}},
+		{name: "Windows daemon", ping: types.Ping{APIVersion: "1.41", OSType: "windows", BuilderVersion: types.BuilderV1}},
+	}
+	for _, tt := range unavailable {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &buildSecretsClient{ping: tt.ping}
+			p := newProvider(t, cli)
+
+			_, err := p.BuildImage(context.Background(), req)
+			require.ErrorIs(t, err, ErrBuildKitNotAvailable)
+			assert.Empty(t, cli.options, "the image should not be built")
+		})
+	}
+}
+
+func TestBuildImageWithSecrets(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	tag, err := provider.BuildImage(ctx, &ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			Context:    "./testresources",
+			Dockerfile: "secret.Dockerfile",
+			Secrets:    map[string]string{"token": "s3cr3t"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = provider.client.ImageRemove(ctx, tag, types.ImageRemoveOptions{Force: true})
+	require.NoError(t, err)
+}
+
 func Test_BuildContainerFromDockerfileWithBuildLog(t *testing.T) {
 	rescueStdout := os.Stderr
 	r, w, _ := os.Pipe()
@@ -1294,6 +1722,32 @@ func Test_BuildContainerFromDockerfileWithBuildLog(t *testing.T) {
 	}
 }
 
+func Test_BuildContainerFromDockerfileWithBuildLogWriter(t *testing.T) {
+	ctx := context.Background()
+	buildLog := &bytes.Buffer{}
+
+	req := ContainerRequest{
+		FromDockerfile: FromDockerfile{
+			Context:        "./testresources",
+			Dockerfile:     "buildlog.Dockerfile",
+			BuildLogWriter: buildLog,
+		},
+	}
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	// the progress is decoded, not written as the JSON messages sent by Docker
+	assert.Regexp(t, `(?i)Step\s*1/1\s*:\s*FROM docker.io/alpine`, buildLog.String())
+	assert.NotContains(t, buildLog.String(), `{"stream"`)
+}
+
 func TestContainerCreationWaitsForLogAndPortContextTimeout(t *testing.T) {
 	ctx := context.Background()
 	req := ContainerRequest{
@@ -1644,11 +2098,33 @@ func TestReadTCPropsFile(t *testing.T) {
 					RyukPrivileged: false,
 				},
 			},
-		}
-		for i, tt := range tests {
-			t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
-				tmpDir := t.TempDir()
-				t.Setenv("HOME", tmpDir)
+			{
+				`ryuk.disabled=true`,
+				map[string]string{},
+				TestContainersConfig{
+					Host:         "",
+					TLSVerify:    0,
+					CertPath:     "",
+					RyukDisabled: true,
+				},
+			},
+			{
+				`ryuk.disabled=false`,
+				map[string]string{
+					"TESTCONTAINERS_RYUK_DISABLED": "true",
+				},
+				TestContainersConfig{
+					Host:         "",
+					TLSVerify:    0,
+					CertPath:     "",
+					RyukDisabled: true,
+				},
+			},
+		}
+		for i, tt := range tests {
+			t.Run(fmt.Sprintf("[%d]", i), func(t *testing.T) {
+				tmpDir := t.TempDir()
+				t.Setenv("HOME", tmpDir)
 				for k, v := range tt.env {
 					t.Setenv(k, v)
 				}
@@ -1666,6 +2142,78 @@ func TestReadTCPropsFile(t *testing.T) {
 	})
 }
 
+func Test_DockerSocketPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	assert.Equal(t, []string{
+		"/var/run/docker.sock",
+		"/run/user/1000/docker.sock",
+		filepath.Join(home, ".docker", "run", "docker.sock"),
+		"/run/user/1000/podman/podman.sock",
+		"/run/podman/podman.sock",
+	}, dockerSocketPaths())
+
+	t.Run("without runtime directory", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", "")
+
+		assert.Equal(t, []string{
+			"/var/run/docker.sock",
+			filepath.Join(home, ".docker", "run", "docker.sock"),
+			"/run/podman/podman.sock",
+		}, dockerSocketPaths())
+	})
+}
+
+func Test_DetectDockerSocket(t *testing.T) {
+	runtimeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(runtimeDir, "podman"), 0o755))
+
+	podmanSocket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	listener, err := net.Listen("unix", podmanSocket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// a regular file is not a socket, e.g. a stale file left behind
+	staleSocket := filepath.Join(runtimeDir, "docker.sock")
+	require.NoError(t, os.WriteFile(staleSocket, nil, 0o600))
+
+	t.Run("first socket", func(t *testing.T) {
+		paths := []string{filepath.Join(runtimeDir, "missing.sock"), staleSocket, podmanSocket}
+		assert.Equal(t, podmanSocket, detectDockerSocket(paths))
+	})
+
+	t.Run("no socket", func(t *testing.T) {
+		assert.Empty(t, detectDockerSocket([]string{staleSocket}))
+	})
+
+	t.Run("Podman socket of the runtime directory", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+		t.Setenv("HOME", t.TempDir())
+
+		// the Docker socket takes precedence when both are available
+		expected := podmanSocket
+		if detectDockerSocket([]string{"/var/run/docker.sock"}) != "" {
+			expected = "/var/run/docker.sock"
+		}
+		assert.Equal(t, expected, detectDockerSocket(dockerSocketPaths()))
+	})
+}
+
+func Test_NewDockerClientWithPodmanSocket(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dockerHost := "unix://" + filepath.Join(t.TempDir(), "podman", "podman.sock")
+	t.Setenv("DOCKER_HOST", dockerHost)
+
+	cli, host, _, err := NewDockerClient()
+	require.NoError(t, err)
+	defer cli.Close()
+
+	assert.Equal(t, dockerHost, host)
+	assert.Equal(t, dockerHost, cli.DaemonHost())
+}
+
 func ExampleDockerProvider_CreateContainer() {
 	ctx := context.Background()
 	req := ContainerRequest{
@@ -1811,6 +2359,51 @@ func TestContainerCreationWithBindAndVolume(t *testing.T) {
 	require.NoError(t, bashC.Terminate(ctx))
 }
 
+func TestContainerWithNamedVolumeSharedBetweenContainers(t *testing.T) {
+	ctx := context.Background()
+	// the volume does not exist yet: it is created by the first mount, and removed by the reaper
+	volumeName := "testcontainers-" + uuid.NewString()
+	mounts := Mounts(ContainerMount{
+		Source: GenericVolumeMountSource{Name: volumeName, Reaped: true},
+		Target: "/data",
+	})
+
+	run := func(cmd string) Container {
+		c, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:      "docker.io/alpine:latest",
+				Mounts:     mounts,
+				Cmd:        []string{"sh", "-c", cmd},
+				WaitingFor: wait.ForExit(),
+			},
+			Started: true,
+		})
+		require.NoError(t, err)
+		terminateContainerOnEnd(t, ctx, c)
+
+		return c
+	}
+
+	run("echo persisted > /data/file")
+	reader := run("cat /data/file")
+
+	r, err := reader.Logs(ctx)
+	require.NoError(t, err)
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "persisted")
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	vol, err := provider.client.VolumeInspect(ctx, volumeName)
+	require.NoError(t, err)
+	assert.Equal(t, "true", vol.Labels[TestcontainerLabel])
+	assert.NotEmpty(t, vol.Labels[TestcontainerLabelSessionID])
+}
+
 func TestContainerWithTmpFs(t *testing.T) {
 	ctx := context.Background()
 	req := ContainerRequest{
@@ -1942,6 +2535,37 @@ func TestContainerCustomPlatformImage(t *testing.T) {
 		assert.Equal(t, "linux", img.Os)
 		assert.Equal(t, "amd64", img.Architecture)
 	})
+
+	t.Run("platform of a multi-arch image should be pulled", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+
+		// the container is not started, so the platform does not need to match the Docker host
+		c, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:         "docker.io/alpine:3.17",
+				SkipReaper:    true,
+				ImagePlatform: "linux/arm64",
+			},
+			Started: false,
+		})
+
+		require.NoError(t, err)
+		terminateContainerOnEnd(t, ctx, c)
+
+		dockerCli, _, _, err := NewDockerClient()
+		require.NoError(t, err)
+
+		dockerCli.NegotiateAPIVersion(ctx)
+		ctr, err := dockerCli.ContainerInspect(ctx, c.GetContainerID())
+		require.NoError(t, err)
+
+		img, _, err := dockerCli.ImageInspectWithRaw(ctx, ctr.Image)
+		require.NoError(t, err)
+		assert.Equal(t, "linux", img.Os)
+		assert.Equal(t, "arm64", img.Architecture)
+	})
 }
 
 func TestContainerWithCustomHostname(t *testing.T) {
@@ -1965,6 +2589,99 @@ func TestContainerWithCustomHostname(t *testing.T) {
 	if actualHostname := readHostname(t, container.GetContainerID()); actualHostname != hostname {
 		t.Fatalf("expected hostname %s, got %s", hostname, actualHostname)
 	}
+
+	// the hostname is also the one seen by the processes running in the container
+	c, reader, err := container.Exec(ctx, []string{"hostname"}, tcexec.Multiplexed())
+	require.NoError(t, err)
+	require.Zero(t, c)
+
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, hostname, strings.TrimSpace(string(output)))
+}
+
+func TestContainerWithReadOnlyRootfs(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:          "docker.io/busybox",
+		Cmd:            []string{"sleep", "10"},
+		Tmpfs:          map[string]string{"/testtmpfs": "rw"},
+		ReadOnlyRootfs: true,
+	}
+
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	path := "/test.file"
+	c, _, err := container.Exec(ctx, []string{"touch", path})
+	require.NoError(t, err)
+	if c == 0 {
+		t.Fatalf("File %s should not have been created in the read only root filesystem", path)
+	}
+
+	path = "/testtmpfs/test.file"
+	c, _, err = container.Exec(ctx, []string{"touch", path})
+	require.NoError(t, err)
+	if c != 0 {
+		t.Fatalf("File %s should have been created successfully in the tmpfs, expected return code 0, got %v", path, c)
+	}
+}
+
+func TestContainerWithExtraHosts(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/busybox",
+		Cmd:        []string{"sleep", "10"},
+		ExtraHosts: []string{"host.docker.internal:host-gateway", "mock.server:10.0.0.1"},
+	}
+
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	c, reader, err := container.Exec(ctx, []string{"cat", "/etc/hosts"}, tcexec.Multiplexed())
+	require.NoError(t, err)
+	require.Zero(t, c)
+
+	hosts, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Regexp(t, `(?m)^10\.0\.0\.1\s+mock\.server$`, string(hosts))
+	assert.Regexp(t, `(?m)^\S+\s+host\.docker\.internal$`, string(hosts))
+}
+
+func TestContainerWithShmSize(t *testing.T) {
+	ctx := context.Background()
+	shmSize := int64(256 * 1024 * 1024)
+	req := ContainerRequest{
+		Image:   nginxAlpineImage,
+		ShmSize: shmSize,
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	containerClient, _, _, err := NewDockerClient()
+	require.NoError(t, err)
+
+	containerDetails, err := containerClient.ContainerInspect(ctx, container.GetContainerID())
+	require.NoError(t, err)
+	assert.Equal(t, shmSize, containerDetails.HostConfig.ShmSize)
 }
 
 func readHostname(tb testing.TB, containerId string) string {
@@ -1981,6 +2698,43 @@ func readHostname(tb testing.TB, containerId string) string {
 	return containerDetails.Config.Hostname
 }
 
+func TestContainerWithFilesReadByTheEntrypoint(t *testing.T) {
+	ctx := context.Background()
+
+	// the configuration is generated, and read by nginx when it starts
+	config := "server { listen 8080; location / { return 200 'configured by the test'; } }"
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxHighPort},
+			WaitingFor:   wait.ForListeningPort(nginxHighPort),
+			Files: []ContainerFile{
+				{
+					Reader:            strings.NewReader(config),
+					ContainerFilePath: "/etc/nginx/conf.d/default.conf",
+					FileMode:          0o644,
+				},
+			},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	endpoint, err := nginxC.PortEndpoint(ctx, nginxHighPort, "http")
+	require.NoError(t, err)
+
+	resp, err := http.Get(endpoint)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "configured by the test", string(body))
+}
+
 func TestDockerContainerCopyFileToContainer(t *testing.T) {
 	ctx := context.Background()
 
@@ -2035,6 +2789,38 @@ func TestDockerContainerCopyDirToContainer(t *testing.T) {
 	assertExtractedFiles(t, ctx, nginxC, "./testresources", "/tmp/testresources/")
 }
 
+func TestDockerContainerCopyNestedDirToContainer(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	// an absolute host path with two levels of directories
+	src := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "app.conf"), []byte("app"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "nested", "db.conf"), []byte("db"), 0644))
+
+	err = nginxC.CopyDirToContainer(ctx, src, "/tmp/config", 700)
+	require.NoError(t, err)
+
+	for _, path := range []string{"/tmp/config/app.conf", "/tmp/config/nested/db.conf"} {
+		c, _, err := nginxC.Exec(ctx, []string{"ls", path})
+		require.NoError(t, err)
+		assert.Zero(t, c, "File %s should exist", path)
+	}
+}
+
 func TestDockerCreateContainerWithFiles(t *testing.T) {
 	ctx := context.Background()
 	hostFileName := "./testresources/hello.sh"
@@ -2196,49 +2982,36 @@ func TestDockerContainerCopyToContainer(t *testing.T) {
 	}
 }
 
-func TestDockerContainerCopyFileFromContainer(t *testing.T) {
-	fileContent, err := os.ReadFile("./testresources/hello.sh")
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestContainerWithSecrets(t *testing.T) {
 	ctx := context.Background()
 
-	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+	c, err := GenericContainer(ctx, GenericContainerRequest{
 		ProviderType: providerType,
 		ContainerRequest: ContainerRequest{
-			Image:        nginxImage,
-			ExposedPorts: []string{nginxDefaultPort},
-			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+			Image:   "docker.io/alpine:latest",
+			Cmd:     []string{"sleep", "60"},
+			Secrets: map[string]string{"db_password": "s3cr3t"},
 		},
 		Started: true,
 	})
-
 	require.NoError(t, err)
-	terminateContainerOnEnd(t, ctx, nginxC)
+	terminateContainerOnEnd(t, ctx, c)
 
-	copiedFileName := "hello_copy.sh"
-	_ = nginxC.CopyFileToContainer(ctx, "./testresources/hello.sh", "/"+copiedFileName, 700)
-	c, _, err := nginxC.Exec(ctx, []string{"bash", copiedFileName})
-	if err != nil {
-		t.Fatal(err)
-	}
-	if c != 0 {
-		t.Fatalf("File %s should exist, expected return code 0, got %v", copiedFileName, c)
-	}
+	exec := func(cmd ...string) string {
+		code, reader, err := c.Exec(ctx, cmd, tcexec.Multiplexed())
+		require.NoError(t, err)
+		require.Equal(t, 0, code)
 
-	reader, err := nginxC.CopyFileFromContainer(ctx, "/"+copiedFileName)
-	if err != nil {
-		t.Fatal(err)
+		b, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return strings.TrimSpace(string(b))
 	}
 
-	fileContentFromContainer, err := io.ReadAll(reader)
-	if err != nil {
-		t.Fatal(err)
-	}
-	assert.Equal(t, fileContent, fileContentFromContainer)
+	assert.Equal(t, "s3cr3t", exec("cat", "/run/secrets/db_password"))
+	assert.Equal(t, "400", exec("stat", "-c", "%a", "/run/secrets/db_password"))
 }
 
-func TestDockerContainerCopyEmptyFileFromContainer(t *testing.T) {
+func TestDockerContainerCopyReaderToContainer(t *testing.T) {
 	ctx := context.Background()
 
 	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
@@ -2250,33 +3023,196 @@ func TestDockerContainerCopyEmptyFileFromContainer(t *testing.T) {
 		},
 		Started: true,
 	})
-
 	require.NoError(t, err)
 	terminateContainerOnEnd(t, ctx, nginxC)
 
-	copiedFileName := "hello_copy.sh"
-	_ = nginxC.CopyFileToContainer(ctx, "./testresources/empty.sh", "/"+copiedFileName, 700)
-	c, _, err := nginxC.Exec(ctx, []string{"bash", copiedFileName})
-	if err != nil {
-		t.Fatal(err)
-	}
-	if c != 0 {
-		t.Fatalf("File %s should exist, expected return code 0, got %v", copiedFileName, c)
-	}
+	// a 10MB stream, which is never held in memory
+	size := int64(10 * 1024 * 1024)
+	reader := io.LimitReader(rand.New(rand.NewSource(1)), size)
 
-	reader, err := nginxC.CopyFileFromContainer(ctx, "/"+copiedFileName)
-	if err != nil {
-		t.Fatal(err)
-	}
+	err = nginxC.CopyReaderToContainer(ctx, reader, size, "/tmp/stream.bin", 0644)
+	require.NoError(t, err)
 
-	fileContentFromContainer, err := io.ReadAll(reader)
-	if err != nil {
-		t.Fatal(err)
-	}
-	assert.Empty(t, fileContentFromContainer)
+	c, output, err := nginxC.Exec(ctx, []string{"stat", "-c", "%s", "/tmp/stream.bin"}, tcexec.Multiplexed())
+	require.NoError(t, err)
+	require.Equal(t, 0, c)
+
+	b, err := io.ReadAll(output)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(size, 10), strings.TrimSpace(string(b)))
 }
 
-func TestDockerContainerResources(t *testing.T) {
+func TestDockerContainerCommit(t *testing.T) {
+	ctx := context.Background()
+
+	seeded, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image: "docker.io/alpine:latest",
+			Cmd:   []string{"sleep", "60"},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, seeded)
+
+	c, _, err := seeded.Exec(ctx, []string{"sh", "-c", "echo seeded > /seed.txt"})
+	require.NoError(t, err)
+	require.Equal(t, 0, c)
+
+	imageRef := "testcontainers/seeded:" + uuid.NewString()
+	imageID, err := seeded.Commit(ctx, imageRef, WithCommitAuthor("testcontainers"), WithCommitMessage("seeded"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, imageID)
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := provider.client.ImageRemove(ctx, imageID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+		require.NoError(t, err)
+	})
+
+	image, _, err := provider.client.ImageInspectWithRaw(ctx, imageRef)
+	require.NoError(t, err)
+	assert.Equal(t, "testcontainers", image.Author)
+	assert.Equal(t, "seeded", image.Comment)
+
+	derived, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:      imageRef,
+			Cmd:        []string{"cat", "/seed.txt"},
+			PullPolicy: PullNever,
+			WaitingFor: wait.ForLog("seeded"),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, derived)
+}
+
+// commitRecorderClient records the options of the commits
+type commitRecorderClient struct {
+	client.APIClient
+	options types.ContainerCommitOptions
+}
+
+func (c *commitRecorderClient) ContainerCommit(_ context.Context, _ string, options types.ContainerCommitOptions) (types.IDResponse, error) {
+	c.options = options
+	return types.IDResponse{ID: "sha256:0123456789abcdef"}, nil
+}
+
+func Test_ContainerCommit(t *testing.T) {
+	cli := &commitRecorderClient{}
+	c := &DockerContainer{
+		ID:       "0123456789abcdef",
+		provider: &DockerProvider{client: cli},
+	}
+
+	id, err := c.Commit(context.Background(), "seeded:latest",
+		WithCommitAuthor("John Doe <john@example.com>"),
+		WithCommitMessage("seeded database"),
+		WithCommitChanges("ENV SEEDED=true", `CMD ["redis-server"]`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:0123456789abcdef", id)
+	assert.Equal(t, types.ContainerCommitOptions{
+		Reference: "seeded:latest",
+		Author:    "John Doe <john@example.com>",
+		Comment:   "seeded database",
+		Changes:   []string{"ENV SEEDED=true", `CMD ["redis-server"]`},
+		Pause:     true,
+	}, cli.options)
+}
+
+func TestDockerContainerCopyFileFromContainer(t *testing.T) {
+	fileContent, err := os.ReadFile("./testresources/hello.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	copiedFileName := "hello_copy.sh"
+	_ = nginxC.CopyFileToContainer(ctx, "./testresources/hello.sh", "/"+copiedFileName, 700)
+	c, _, err := nginxC.Exec(ctx, []string{"bash", copiedFileName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 0 {
+		t.Fatalf("File %s should exist, expected return code 0, got %v", copiedFileName, c)
+	}
+
+	reader, err := nginxC.CopyFileFromContainer(ctx, "/"+copiedFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileContentFromContainer, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fileContent, fileContentFromContainer)
+	require.NoError(t, reader.Close())
+
+	_, err = nginxC.CopyFileFromContainer(ctx, "/etc")
+	assert.EqualError(t, err, "path /etc is a directory, not a file")
+
+	_, err = nginxC.CopyFileFromContainer(ctx, "/missing_"+copiedFileName)
+	assert.True(t, errdefs.IsNotFound(err), "expected a not found error, got %v", err)
+}
+
+func TestDockerContainerCopyEmptyFileFromContainer(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	copiedFileName := "hello_copy.sh"
+	_ = nginxC.CopyFileToContainer(ctx, "./testresources/empty.sh", "/"+copiedFileName, 700)
+	c, _, err := nginxC.Exec(ctx, []string{"bash", copiedFileName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != 0 {
+		t.Fatalf("File %s should exist, expected return code 0, got %v", copiedFileName, c)
+	}
+
+	reader, err := nginxC.CopyFileFromContainer(ctx, "/"+copiedFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileContentFromContainer, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, fileContentFromContainer)
+}
+
+func TestDockerContainerResources(t *testing.T) {
 	if providerType == ProviderPodman {
 		t.Skip("Rootless Podman does not support setting rlimit")
 	}
@@ -2322,122 +3258,974 @@ func TestDockerContainerResources(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, expected, resp.HostConfig.Ulimits)
+
+	// the raised limit applies to the processes of the container
+	code, reader, err := nginxC.Exec(ctx, []string{"sh", "-c", "ulimit -n"}, tcexec.Multiplexed())
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "65536", strings.TrimSpace(string(b)))
 }
 
-func TestContainerWithReaperNetwork(t *testing.T) {
-	ctx := context.Background()
-	networks := []string{
-		"test_network_" + randomString(),
-		"test_network_" + randomString(),
+// imageArchiveClient saves the images as the given archive, and loads the archives reporting the given messages
+type imageArchiveClient struct {
+	client.APIClient
+	archive  []byte
+	loaded   []byte
+	messages []jsonmessage.JSONMessage
+}
+
+func (c *imageArchiveClient) ImageSave(_ context.Context, _ []string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(c.archive)), nil
+}
+
+func (c *imageArchiveClient) ImageLoad(_ context.Context, input io.Reader, _ bool) (types.ImageLoadResponse, error) {
+	b, err := io.ReadAll(input)
+	if err != nil {
+		return types.ImageLoadResponse{}, err
 	}
+	c.loaded = b
 
-	for _, nw := range networks {
-		nr := NetworkRequest{
-			Name:       nw,
-			Attachable: true,
+	output := &bytes.Buffer{}
+	for _, msg := range c.messages {
+		if err := json.NewEncoder(output).Encode(msg); err != nil {
+			return types.ImageLoadResponse{}, err
 		}
-		_, err := GenericNetwork(ctx, GenericNetworkRequest{
-			ProviderType:   providerType,
-			NetworkRequest: nr,
+	}
+	return types.ImageLoadResponse{Body: io.NopCloser(output), JSON: true}, nil
+}
+
+func Test_SaveAndLoadImage(t *testing.T) {
+	ctx := context.Background()
+	tarPath := filepath.Join(t.TempDir(), "image.tar")
+
+	t.Run("round trip", func(t *testing.T) {
+		cli := &imageArchiveClient{
+			archive: []byte("image archive"),
+			messages: []jsonmessage.JSONMessage{
+				{Stream: "Loaded image: docker.io/nginx:alpine\n"},
+				{Stream: "Loaded image ID: sha256:0123456789abcdef\n"},
+			},
+		}
+		p := &DockerProvider{client: cli}
+
+		err := p.SaveImage(ctx, nginxAlpineImage, tarPath)
+		require.NoError(t, err)
+
+		refs, err := p.LoadImage(ctx, tarPath)
+		require.NoError(t, err)
+		assert.Equal(t, cli.archive, cli.loaded)
+		assert.Equal(t, []string{"docker.io/nginx:alpine", "sha256:0123456789abcdef"}, refs)
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		cli := &imageArchiveClient{
+			messages: []jsonmessage.JSONMessage{
+				{Error: &jsonmessage.JSONError{Message: "invalid archive"}},
+			},
+		}
+		p := &DockerProvider{client: cli}
+
+		refs, err := p.LoadImage(ctx, tarPath)
+		require.ErrorContains(t, err, "invalid archive")
+		assert.Empty(t, refs)
+	})
+
+	t.Run("missing archive", func(t *testing.T) {
+		p := &DockerProvider{client: &imageArchiveClient{}}
+
+		_, err := p.LoadImage(ctx, filepath.Join(t.TempDir(), "missing.tar"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestSaveAndLoadImage(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	err = provider.attemptToPullImage(ctx, nginxAlpineImage, types.ImagePullOptions{}, defaultImagePullRetries)
+	require.NoError(t, err)
+
+	tarPath := filepath.Join(t.TempDir(), "nginx.tar")
+	err = provider.SaveImage(ctx, nginxAlpineImage, tarPath)
+	require.NoError(t, err)
+
+	info, err := os.Stat(tarPath)
+	require.NoError(t, err)
+	assert.Positive(t, info.Size())
+
+	refs, err := provider.LoadImage(ctx, tarPath)
+	require.NoError(t, err)
+	assert.Contains(t, refs, "nginx:alpine")
+}
+
+func TestContainerWithImagePinnedByDigest(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	// the digest of the tag is resolved at run time, as the tag is updated by its maintainers
+	err = provider.attemptToPullImage(ctx, "docker.io/alpine:latest", types.ImagePullOptions{}, defaultImagePullRetries)
+	require.NoError(t, err)
+	image, _, err := provider.client.ImageInspectWithRaw(ctx, "docker.io/alpine:latest")
+	require.NoError(t, err)
+	require.NotEmpty(t, image.RepoDigests)
+	pinned := image.RepoDigests[0]
+
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        pinned,
+			PullPolicy:   PullAlways,
+			VerifyDigest: true,
+			Cmd:          []string{"sleep", "300"},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	inspect, err := container.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, pinned, inspect.Config.Image)
+
+	image, _, err = provider.client.ImageInspectWithRaw(ctx, inspect.Image)
+	require.NoError(t, err)
+	assert.Contains(t, image.RepoDigests, pinned)
+}
+
+// pullRecorderClient fails the first pulls with the given errors, then reports the given stream errors
+// in the pull stream of the next pulls, and then pulls the image
+type pullRecorderClient struct {
+	client.APIClient
+	errs       []error
+	streamErrs []jsonmessage.JSONError
+	pulls      int
+}
+
+func (c *pullRecorderClient) ImagePull(_ context.Context, _ string, _ types.ImagePullOptions) (io.ReadCloser, error) {
+	c.pulls++
+	if c.pulls <= len(c.errs) {
+		return nil, c.errs[c.pulls-1]
+	}
+	if i := c.pulls - len(c.errs) - 1; i < len(c.streamErrs) {
+		msg, err := json.Marshal(jsonmessage.JSONMessage{Error: &c.streamErrs[i], ErrorMessage: c.streamErrs[i].Message})
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(msg)), nil
+	}
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image"}`)), nil
+}
+
+func Test_AttemptToPullImage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		recorder := &pullRecorderClient{errs: []error{
+			errdefs.System(errors.New("received unexpected HTTP status: 500 Internal Server Error")),
+			errors.New("read: connection reset by peer"),
+		}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, recorder.pulls)
+	})
+
+	t.Run("rate limits are retried", func(t *testing.T) {
+		recorder := &pullRecorderClient{errs: []error{
+			errdefs.InvalidParameter(errors.New("toomanyrequests: You have reached your pull rate limit")),
+		}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorder.pulls)
+	})
+
+	t.Run("the number of retries is limited", func(t *testing.T) {
+		unavailable := errdefs.Unavailable(errors.New("service unavailable"))
+		recorder := &pullRecorderClient{errs: []error{unavailable, unavailable, unavailable}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 1)
+		require.Error(t, err)
+		assert.Equal(t, 2, recorder.pulls)
+	})
+
+	t.Run("retries can be disabled", func(t *testing.T) {
+		recorder := &pullRecorderClient{errs: []error{errdefs.Unavailable(errors.New("service unavailable"))}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, -1)
+		require.Error(t, err)
+		assert.Equal(t, 1, recorder.pulls)
+	})
+
+	t.Run("transient stream errors are retried", func(t *testing.T) {
+		recorder := &pullRecorderClient{streamErrs: []jsonmessage.JSONError{
+			{Message: "toomanyrequests: You have reached your pull rate limit"},
+			{Code: http.StatusBadGateway, Message: "bad gateway"},
+		}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, recorder.pulls)
+	})
+
+	t.Run("permanent stream errors are not retried", func(t *testing.T) {
+		recorder := &pullRecorderClient{streamErrs: []jsonmessage.JSONError{
+			{Message: "no matching manifest for linux/s390x in the manifest list entries"},
+		}}
+		provider := &DockerProvider{client: recorder}
+
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{Platform: "linux/s390x"}, 0)
+		require.ErrorContains(t, err, "failed to pull image docker.io/alpine for platform linux/s390x: no matching manifest")
+		assert.Equal(t, 1, recorder.pulls)
+	})
+
+	t.Run("the delay asked by the registry is honored", func(t *testing.T) {
+		recorder := &pullRecorderClient{errs: []error{
+			errors.New("toomanyrequests: retry later, Retry-After: 2"),
+		}}
+		provider := &DockerProvider{client: recorder}
+
+		start := time.Now()
+		err := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, recorder.pulls)
+		assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+	})
+
+	for _, err := range []error{
+		errdefs.NotFound(errors.New("manifest unknown")),
+		errdefs.Unauthorized(errors.New("authentication required")),
+	} {
+		t.Run("permanent error: "+err.Error(), func(t *testing.T) {
+			recorder := &pullRecorderClient{errs: []error{err}}
+			provider := &DockerProvider{client: recorder}
+
+			pullErr := provider.attemptToPullImage(ctx, "docker.io/alpine", types.ImagePullOptions{}, 0)
+			require.ErrorIs(t, pullErr, err)
+			assert.Equal(t, 1, recorder.pulls)
+		})
+	}
+}
+
+func Test_PullRetryAfter(t *testing.T) {
+	assert.Equal(t, 30*time.Second, pullRetryAfter(errors.New("toomanyrequests: Retry-After: 30")))
+	assert.Equal(t, 5*time.Second, pullRetryAfter(errors.New("too many requests, retry-after 5")))
+	assert.Equal(t, maxImagePullRetryAfter, pullRetryAfter(errors.New("toomanyrequests: Retry-After: 21600")))
+	assert.Zero(t, pullRetryAfter(errors.New("toomanyrequests: You have reached your pull rate limit")))
+}
+
+// inspectImageClient inspects the given local images, the other images are not found
+type inspectImageClient struct {
+	client.APIClient
+	images map[string]types.ImageInspect
+}
+
+func (c *inspectImageClient) ImageInspectWithRaw(_ context.Context, tag string) (types.ImageInspect, []byte, error) {
+	image, ok := c.images[tag]
+	if !ok {
+		return types.ImageInspect{}, nil, errdefs.NotFound(fmt.Errorf("no such image: %s", tag))
+	}
+	return image, nil, nil
+}
+
+func Test_ShouldPullImage(t *testing.T) {
+	ctx := context.Background()
+	provider := &DockerProvider{client: &inspectImageClient{images: map[string]types.ImageInspect{
+		"docker.io/alpine": {Os: "linux", Architecture: "amd64"},
+	}}}
+	arm64 := &specs.Platform{OS: "linux", Architecture: "arm64"}
+
+	testCases := []struct {
+		name       string
+		req        ContainerRequest
+		platform   *specs.Platform
+		shouldPull bool
+		err        error
+	}{
+		{name: "IfNotPresent with a local image", req: ContainerRequest{Image: "docker.io/alpine"}},
+		{name: "IfNotPresent without local image", req: ContainerRequest{Image: "docker.io/nginx"}, shouldPull: true},
+		{name: "IfNotPresent with another local platform", req: ContainerRequest{Image: "docker.io/alpine", ImagePlatform: "linux/arm64"}, platform: arm64, shouldPull: true},
+		{name: "Always with a local image", req: ContainerRequest{Image: "docker.io/alpine", PullPolicy: PullAlways}, shouldPull: true},
+		{name: "AlwaysPullImage with a local image", req: ContainerRequest{Image: "docker.io/alpine", AlwaysPullImage: true}, shouldPull: true},
+		{name: "Never with a local image", req: ContainerRequest{Image: "docker.io/alpine", PullPolicy: PullNever}},
+		{name: "Never without local image", req: ContainerRequest{Image: "docker.io/nginx", PullPolicy: PullNever}, err: ErrImageNotPresent},
+		{name: "Never with another local platform", req: ContainerRequest{Image: "docker.io/alpine", ImagePlatform: "linux/arm64", PullPolicy: PullNever}, platform: arm64, err: ErrImageNotPresent},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			shouldPull, err := provider.shouldPullImage(ctx, tc.req, tc.req.Image, tc.platform)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.shouldPull, shouldPull)
 		})
-		assert.Nil(t, err)
 	}
+}
+
+func TestContainerWithTmpfs(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image: "docker.io/alpine",
+			Cmd:   []string{"sleep", "60"},
+			Tmpfs: map[string]string{"/data": "rw,size=32m,mode=1777"},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	// the size is reported in 1K blocks
+	code, reader, err := c.Exec(ctx, []string{"df", "-k", "/data"}, tcexec.Multiplexed())
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Regexp(t, `tmpfs\s+32768\s+`, string(output))
+}
+
+func TestContainerLogDumpOnFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:      "docker.io/alpine",
+			Cmd:        []string{"sh", "-c", "echo 'failed to connect to the database' && exit 1"},
+			WaitingFor: wait.ForLog("ready").WithStartupTimeout(5 * time.Second),
+			LogDumpDir: dir,
+		},
+		Started: true,
+	})
+	require.Error(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	content, err := os.ReadFile(filepath.Join(dir, c.GetContainerID()[:12]+"-"+c.SessionID()+".log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "failed to connect to the database")
+}
+
+func TestContainerWithRestartPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	// the process crashes a second after it starts, so that Docker restarts the container
+	c, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:         "docker.io/alpine",
+			Cmd:           []string{"sh", "-c", "sleep 1 && exit 1"},
+			RestartPolicy: container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 5},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	dockerClient, _, _, err := NewDockerClient()
+	require.NoError(t, err)
+	defer dockerClient.Close()
+
+	resp, err := dockerClient.ContainerInspect(ctx, c.GetContainerID())
+	require.NoError(t, err)
+	assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 5}, resp.HostConfig.RestartPolicy)
+
+	assert.Eventually(t, func() bool {
+		resp, err := dockerClient.ContainerInspect(ctx, c.GetContainerID())
+		return err == nil && resp.RestartCount > 0
+	}, 30*time.Second, 500*time.Millisecond, "the container should have been restarted")
+}
+
+func TestContainerWithResources(t *testing.T) {
+	ctx := context.Background()
+	resources := container.Resources{
+		Memory:   64 * 1024 * 1024,
+		NanoCPUs: 500000000,
+	}
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+			Resources:    resources,
+		},
+		Started: true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	c, err := client.NewClientWithOpts(client.FromEnv)
+	require.NoError(t, err)
+
+	c.NegotiateAPIVersion(ctx)
+
+	resp, err := c.ContainerInspect(ctx, nginxC.GetContainerID())
+	require.NoError(t, err)
+
+	assert.Equal(t, resources.Memory, resp.HostConfig.Memory)
+	assert.Equal(t, resources.NanoCPUs, resp.HostConfig.NanoCPUs)
+}
+
+func TestContainerUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+			Resources: container.Resources{
+				Memory:     128 * 1024 * 1024,
+				MemorySwap: 256 * 1024 * 1024,
+			},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	err = nginxC.Update(ctx, container.UpdateConfig{
+		Resources: container.Resources{
+			Memory:   64 * 1024 * 1024,
+			CPUQuota: 50000,
+		},
+		RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
+	})
+	require.NoError(t, err)
+
+	inspect, err := nginxC.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(64*1024*1024), inspect.HostConfig.Memory)
+	assert.Equal(t, int64(256*1024*1024), inspect.HostConfig.MemorySwap, "the zero values should be left unchanged")
+	assert.Equal(t, int64(50000), inspect.HostConfig.CPUQuota)
+	assert.Equal(t, "unless-stopped", inspect.HostConfig.RestartPolicy.Name)
+}
+
+// updateRecorderClient records the updates of the containers, and returns the given warnings
+type updateRecorderClient struct {
+	client.APIClient
+	config   container.UpdateConfig
+	warnings []string
+}
+
+func (c *updateRecorderClient) ContainerUpdate(_ context.Context, _ string, config container.UpdateConfig) (container.ContainerUpdateOKBody, error) {
+	c.config = config
+	return container.ContainerUpdateOKBody{Warnings: c.warnings}, nil
+}
+
+func Test_ContainerUpdate(t *testing.T) {
+	recorder := &updateRecorderClient{warnings: []string{"swap limit not supported"}}
+	c := &DockerContainer{
+		ID:           "0123456789abcdef",
+		provider:     &DockerProvider{client: recorder},
+		logger:       TestLogger(t),
+		inspectCache: []byte("{}"),
+		inspectedAt:  time.Now(),
+	}
+
+	config := container.UpdateConfig{Resources: container.Resources{Memory: 64 * 1024 * 1024}}
+	require.NoError(t, c.Update(context.Background(), config))
+	assert.Equal(t, config, recorder.config)
+	assert.Nil(t, c.inspectCache, "the inspect cache should be invalidated")
+}
+
+func TestContainerWithReaperNetwork(t *testing.T) {
+	ctx := context.Background()
+	networks := []string{
+		"test_network_" + randomString(),
+		"test_network_" + randomString(),
+	}
+
+	for _, nw := range networks {
+		nr := NetworkRequest{
+			Name:       nw,
+			Attachable: true,
+		}
+		_, err := GenericNetwork(ctx, GenericNetworkRequest{
+			ProviderType:   providerType,
+			NetworkRequest: nr,
+		})
+		assert.Nil(t, err)
+	}
+
+	req := ContainerRequest{
+		Image:        nginxAlpineImage,
+		ExposedPorts: []string{nginxDefaultPort},
+		WaitingFor: wait.ForAll(
+			wait.ForListeningPort(nginxDefaultPort),
+			wait.ForLog("Configuration complete; ready for start up"),
+		),
+		Networks: networks,
+	}
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	containerId := nginxC.GetContainerID()
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	assert.Nil(t, err)
+	cnt, err := cli.ContainerInspect(ctx, containerId)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cnt.NetworkSettings.Networks))
+	assert.NotNil(t, cnt.NetworkSettings.Networks[networks[0]])
+	assert.NotNil(t, cnt.NetworkSettings.Networks[networks[1]])
+}
+
+func TestContainerCapAdd(t *testing.T) {
+	if providerType == ProviderPodman {
+		t.Skip("Rootless Podman does not support setting cap-add/cap-drop")
+	}
+
+	ctx := context.Background()
+
+	expected := "IPC_LOCK"
+
+	nginx, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+			CapAdd:       []string{expected},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginx)
+
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	require.NoError(t, err)
+	defer dockerClient.Close()
+
+	containerID := nginx.GetContainerID()
+	resp, err := dockerClient.ContainerInspect(ctx, containerID)
+	require.NoError(t, err)
+
+	assert.Equal(t, strslice.StrSlice{expected}, resp.HostConfig.CapAdd)
+}
+
+func TestContainerRunningCheckingStatusCode(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:        "influxdb:1.8.10-alpine",
+		ExposedPorts: []string{"8086/tcp"},
+		WaitingFor: wait.ForAll(
+			wait.ForHTTP("/ping").WithPort("8086/tcp").WithStatusCodeMatcher(
+				func(status int) bool {
+					return status == http.StatusNoContent
+				},
+			),
+		),
+	}
+
+	influx, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terminateContainerOnEnd(t, ctx, influx)
+}
+
+func TestContainerWithUserID(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		User:       "60125",
+		Cmd:        []string{"sh", "-c", "id -u"},
+		WaitingFor: wait.ForExit(),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	r, err := container.Logs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := regexp.MustCompile(`\D+`).ReplaceAllString(string(b), "")
+	assert.Equal(t, req.User, actual)
+}
+
+func TestContainerWithUserAndGroup(t *testing.T) {
+	tests := []struct {
+		user        string
+		expectedUID string
+		expectedGID string
+	}{
+		{user: "60125:60126", expectedUID: "60125", expectedGID: "60126"},
+		// alpine defines the nobody user in the nobody group
+		{user: "nobody:nobody", expectedUID: "65534", expectedGID: "65534"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			ctx := context.Background()
+			req := ContainerRequest{
+				Image: "docker.io/alpine:latest",
+				User:  tt.user,
+				Cmd:   []string{"sleep", "10"},
+			}
+			container, err := GenericContainer(ctx, GenericContainerRequest{
+				ProviderType:     providerType,
+				ContainerRequest: req,
+				Started:          true,
+			})
+
+			require.NoError(t, err)
+			terminateContainerOnEnd(t, ctx, container)
+
+			id := func(flag string) string {
+				c, reader, err := container.Exec(ctx, []string{"id", flag}, tcexec.Multiplexed())
+				require.NoError(t, err)
+				require.Zero(t, c)
+
+				output, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				return strings.TrimSpace(string(output))
+			}
+
+			assert.Equal(t, tt.expectedUID, id("-u"))
+			assert.Equal(t, tt.expectedGID, id("-g"))
+		})
+	}
+}
+
+func TestContainerWithGPU(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	info, err := provider.client.Info(ctx)
+	require.NoError(t, err)
+	if _, ok := info.Runtimes["nvidia"]; !ok {
+		t.Skip("the NVIDIA Container Toolkit is not installed on the Docker host")
+	}
+
+	req := ContainerRequest{
+		Image: "docker.io/ubuntu:22.04",
+		// the utility capability mounts nvidia-smi in the container
+		GPUs:       &GPURequest{Count: 1, Capabilities: []string{"utility"}},
+		Cmd:        []string{"nvidia-smi", "-L"},
+		WaitingFor: wait.ForExit().WithExitCode(0),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	r, err := container.Logs(ctx)
+	require.NoError(t, err)
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "GPU 0")
+}
+
+func TestContainerStartErrorWithLogs(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		Cmd:        []string{"sh", "-c", "echo 'missing configuration' >&2 && exit 3"},
+		WaitingFor: wait.ForLog("ready").WithStartupTimeout(5 * time.Second),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+	terminateContainerOnEnd(t, ctx, container)
+
+	var startErr *ContainerStartError
+	require.ErrorAs(t, err, &startErr)
+	assert.Equal(t, 3, startErr.ExitCode)
+	assert.Contains(t, startErr.Logs, "missing configuration")
+	assert.ErrorContains(t, err, "missing configuration")
+}
+
+func TestContainerWithStopSignal(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		StopSignal: "SIGQUIT",
+		// the shell is the process 1 of the container, which only handles the trapped signals
+		Cmd:        []string{"sh", "-c", "trap 'echo stopped by SIGQUIT; exit 0' QUIT; echo started; while true; do sleep 0.1; done"},
+		WaitingFor: wait.ForLog("started"),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	inspect, err := container.Inspect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "SIGQUIT", inspect.Config.StopSignal)
+
+	timeout := 10 * time.Second
+	require.NoError(t, container.Stop(ctx, &timeout))
+
+	state, err := container.State(ctx)
+	require.NoError(t, err)
+	// the container would exit with 137 if it was killed after the timeout
+	assert.Equal(t, 0, state.ExitCode)
+
+	r, err := container.Logs(ctx)
+	require.NoError(t, err)
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "stopped by SIGQUIT")
+}
+
+func TestContainerWithStartupCommand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates the file of the wait strategy", func(t *testing.T) {
+		container, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:          "docker.io/alpine:latest",
+				Cmd:            []string{"sleep", "300"},
+				StartupCommand: []string{"sh", "-c", "mkdir -p /var/lib/app && echo initialized > /var/lib/app/ready"},
+				WaitingFor: wait.ForFile("/var/lib/app/ready").
+					WithStartupTimeout(10 * time.Second).
+					WithMatcher(func(content []byte) bool {
+						return strings.TrimSpace(string(content)) == "initialized"
+					}),
+			},
+			Started: true,
+		})
+		require.NoError(t, err)
+		terminateContainerOnEnd(t, ctx, container)
+	})
+
+	t.Run("failure aborts the start", func(t *testing.T) {
+		container, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:          "docker.io/alpine:latest",
+				Cmd:            []string{"sleep", "300"},
+				StartupCommand: []string{"sh", "-c", "echo cannot initialize >&2; exit 3"},
+				WaitingFor:     wait.ForFile("/var/lib/app/ready").WithStartupTimeout(10 * time.Second),
+			},
+			Started: true,
+		})
+		terminateContainerOnEnd(t, ctx, container)
+
+		require.ErrorIs(t, err, ErrStartupCommandFailed)
+		assert.Contains(t, err.Error(), "exited with code 3")
+		assert.Contains(t, err.Error(), "cannot initialize")
+	})
+}
+
+func TestContainerWithWorkingDir(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		WorkingDir: "/tmp",
+		Cmd:        []string{"sh", "-c", "pwd && sleep 60"},
+		WaitingFor: wait.ForLog("/tmp"),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	pwd := func(options ExecOptions) string {
+		c, reader, _, err := container.ExecWithOptions(ctx, []string{"pwd"}, options)
+		require.NoError(t, err)
+		require.Equal(t, 0, c)
+
+		b, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return strings.TrimSpace(string(b))
+	}
+
+	// the execs inherit the working directory of the container
+	assert.Equal(t, "/tmp", pwd(ExecOptions{}))
+	// unless they override it
+	assert.Equal(t, "/etc", pwd(ExecOptions{WorkingDir: "/etc"}))
+}
+
+func Test_BuildEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		options  EndpointOptions
+		expected string
+	}{
+		{name: "host and port", host: "localhost", expected: "localhost:8080"},
+		{name: "scheme", host: "localhost", options: EndpointOptions{Scheme: "http"}, expected: "http://localhost:8080"},
+		{name: "path", host: "localhost", options: EndpointOptions{Scheme: "https", Path: "/healthz"}, expected: "https://localhost:8080/healthz"},
+		{name: "path without leading slash", host: "localhost", options: EndpointOptions{Scheme: "https", Path: "api/v1/healthz"}, expected: "https://localhost:8080/api/v1/healthz"},
+		{name: "IPv4", host: "192.168.0.10", options: EndpointOptions{Scheme: "http"}, expected: "http://192.168.0.10:8080"},
+		{name: "IPv6", host: "::1", options: EndpointOptions{Scheme: "http", Path: "/healthz"}, expected: "http://[::1]:8080/healthz"},
+		{name: "IPv6 without scheme", host: "fe80::1", expected: "[fe80::1]:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildEndpoint(tt.host, "8080/tcp", tt.options))
+		})
+	}
+}
+
+func Test_EndpointIPAMConfig(t *testing.T) {
+	nw := types.NetworkResource{
+		Name: "my-network",
+		IPAM: network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: "10.1.2.0/24"}},
+		},
+	}
+
+	t.Run("no static address", func(t *testing.T) {
+		config, err := endpointIPAMConfig(nw, "")
+		require.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("address in the subnet", func(t *testing.T) {
+		config, err := endpointIPAMConfig(nw, "10.1.2.3")
+		require.NoError(t, err)
+		assert.Equal(t, &network.EndpointIPAMConfig{IPv4Address: "10.1.2.3"}, config)
+	})
 
+	t.Run("address out of the subnet", func(t *testing.T) {
+		_, err := endpointIPAMConfig(nw, "10.1.3.3")
+		require.ErrorIs(t, err, ErrInvalidIPAddress)
+		assert.EqualError(t, err, "invalid IP address: 10.1.3.3 is not in the subnet of network my-network")
+	})
+}
+
+func TestContainerRestart(t *testing.T) {
+	ctx := context.Background()
 	req := ContainerRequest{
 		Image:        nginxAlpineImage,
 		ExposedPorts: []string{nginxDefaultPort},
-		WaitingFor: wait.ForAll(
-			wait.ForListeningPort(nginxDefaultPort),
-			wait.ForLog("Configuration complete; ready for start up"),
-		),
-		Networks: networks,
+		WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
 	}
-
-	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+	container, err := GenericContainer(ctx, GenericContainerRequest{
 		ProviderType:     providerType,
 		ContainerRequest: req,
 		Started:          true,
 	})
 
 	require.NoError(t, err)
-	terminateContainerOnEnd(t, ctx, nginxC)
-
-	containerId := nginxC.GetContainerID()
-
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	assert.Nil(t, err)
-	cnt, err := cli.ContainerInspect(ctx, containerId)
-	assert.Nil(t, err)
-	assert.Equal(t, 2, len(cnt.NetworkSettings.Networks))
-	assert.NotNil(t, cnt.NetworkSettings.Networks[networks[0]])
-	assert.NotNil(t, cnt.NetworkSettings.Networks[networks[1]])
-}
-
-func TestContainerCapAdd(t *testing.T) {
-	if providerType == ProviderPodman {
-		t.Skip("Rootless Podman does not support setting cap-add/cap-drop")
-	}
+	terminateContainerOnEnd(t, ctx, container)
 
-	ctx := context.Background()
+	before, err := container.State(ctx)
+	require.NoError(t, err)
 
-	expected := "IPC_LOCK"
+	timeout := 5 * time.Second
+	require.NoError(t, container.Restart(ctx, &timeout))
+	assert.True(t, container.IsRunning())
 
-	nginx, err := GenericContainer(ctx, GenericContainerRequest{
-		ProviderType: providerType,
-		ContainerRequest: ContainerRequest{
-			Image:        nginxAlpineImage,
-			ExposedPorts: []string{nginxDefaultPort},
-			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
-			CapAdd:       []string{expected},
-		},
-		Started: true,
-	})
+	after, err := container.State(ctx)
 	require.NoError(t, err)
-	terminateContainerOnEnd(t, ctx, nginx)
+	assert.True(t, after.Running)
+	assert.NotEqual(t, before.StartedAt, after.StartedAt)
 
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	// the wait strategy was run again, so nginx is serving requests
+	endpoint, err := container.PortEndpoint(ctx, nginxDefaultPort, "http")
 	require.NoError(t, err)
-	defer dockerClient.Close()
 
-	containerID := nginx.GetContainerID()
-	resp, err := dockerClient.ContainerInspect(ctx, containerID)
+	resp, err := http.Get(endpoint)
 	require.NoError(t, err)
-
-	assert.Equal(t, strslice.StrSlice{expected}, resp.HostConfig.CapAdd)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
-func TestContainerRunningCheckingStatusCode(t *testing.T) {
+func TestContainerExecWithOptions(t *testing.T) {
 	ctx := context.Background()
 	req := ContainerRequest{
-		Image:        "influxdb:1.8.10-alpine",
-		ExposedPorts: []string{"8086/tcp"},
-		WaitingFor: wait.ForAll(
-			wait.ForHTTP("/ping").WithPort("8086/tcp").WithStatusCodeMatcher(
-				func(status int) bool {
-					return status == http.StatusNoContent
-				},
-			),
-		),
+		Image: "docker.io/alpine:latest",
+		Cmd:   []string{"sleep", "10"},
 	}
-
-	influx, err := GenericContainer(ctx, GenericContainerRequest{
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
 		ContainerRequest: req,
 		Started:          true,
 	})
-	if err != nil {
-		t.Fatal(err)
-	}
 
-	terminateContainerOnEnd(t, ctx, influx)
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	c, stdout, stderr, err := container.ExecWithOptions(ctx, []string{"sh", "-c", "echo $GREETING; echo oops >&2; pwd; id -u; cat"}, ExecOptions{
+		Env:         map[string]string{"GREETING": "hello"},
+		WorkingDir:  "/tmp",
+		User:        "nobody",
+		AttachStdin: true,
+	})
+	require.NoError(t, err)
+	require.Zero(t, c)
+
+	out, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n/tmp\n65534\n", string(out))
+
+	errOut, err := io.ReadAll(stderr)
+	require.NoError(t, err)
+	assert.Equal(t, "oops\n", string(errOut))
 }
 
-func TestContainerWithUserID(t *testing.T) {
+func TestContainerExecWithStdin(t *testing.T) {
 	ctx := context.Background()
 	req := ContainerRequest{
-		Image:      "docker.io/alpine:latest",
-		User:       "60125",
-		Cmd:        []string{"sh", "-c", "id -u"},
-		WaitingFor: wait.ForExit(),
+		Image: "docker.io/alpine:latest",
+		Cmd:   []string{"sleep", "10"},
 	}
 	container, err := GenericContainer(ctx, GenericContainerRequest{
 		ProviderType:     providerType,
@@ -2448,17 +4236,16 @@ func TestContainerWithUserID(t *testing.T) {
 	require.NoError(t, err)
 	terminateContainerOnEnd(t, ctx, container)
 
-	r, err := container.Logs(ctx)
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer r.Close()
-	b, err := io.ReadAll(r)
-	if err != nil {
-		t.Fatal(err)
-	}
-	actual := regexp.MustCompile(`\D+`).ReplaceAllString(string(b), "")
-	assert.Equal(t, req.User, actual)
+	script := "CREATE TABLE users (id INT);\nINSERT INTO users VALUES (1);\n"
+	c, stdout, _, err := container.ExecWithOptions(ctx, []string{"cat"}, ExecOptions{
+		Stdin: strings.NewReader(script),
+	})
+	require.NoError(t, err)
+	require.Zero(t, c)
+
+	out, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+	assert.Equal(t, script, string(out))
 }
 
 func TestContainerWithNoUserID(t *testing.T) {
@@ -2506,6 +4293,161 @@ func TestGetGatewayIP(t *testing.T) {
 	}
 }
 
+func TestProviderWithDefaultLabels(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)), WithDefaultLabels(map[string]string{
+		"build_id": "42",
+		"team":     "platform",
+	}))
+	require.NoError(t, err)
+
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			c, err := provider.CreateContainer(ctx, ContainerRequest{
+				Image:  nginxAlpineImage,
+				Labels: map[string]string{"team": name},
+			})
+			require.NoError(t, err)
+			terminateContainerOnEnd(t, ctx, c)
+
+			inspect, err := c.(*DockerContainer).inspectContainer(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "42", inspect.Config.Labels["build_id"])
+			assert.Equal(t, name, inspect.Config.Labels["team"], "the labels of the request take precedence")
+		})
+	}
+
+	t.Run("reserved labels cannot be set", func(t *testing.T) {
+		reserved, err := NewDockerProvider(WithDefaultLabels(map[string]string{TestcontainerLabelSessionID: "other"}))
+		require.NoError(t, err)
+
+		_, err = reserved.CreateContainer(ctx, ContainerRequest{Image: nginxAlpineImage})
+		require.ErrorIs(t, err, ErrReservedLabel)
+	})
+}
+
+func TestProviderWithDefaultWaitStrategy(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)), WithDefaultWaitStrategy(
+		wait.ForLog("ready").WithStartupTimeout(10*time.Second),
+	))
+	require.NoError(t, err)
+
+	c, err := provider.CreateContainer(ctx, ContainerRequest{
+		Image: "docker.io/alpine:latest",
+		Cmd:   []string{"sh", "-c", "sleep 2 && echo ready && sleep 60"},
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, c)
+
+	// the container is started once it is ready
+	require.NoError(t, c.Start(ctx))
+	r, err := c.Logs(ctx)
+	require.NoError(t, err)
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "ready")
+}
+
+func Test_DefaultWaitStrategy(t *testing.T) {
+	defaultStrategy := wait.ForLog("default")
+	requestStrategy := wait.ForLog("request")
+
+	provider := &DockerProvider{DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{}}}
+	WithDefaultWaitStrategy(defaultStrategy).ApplyDockerTo(provider.DockerProviderOptions)
+
+	t.Run("request without wait strategy", func(t *testing.T) {
+		assert.Equal(t, defaultStrategy, provider.waitStrategy(ContainerRequest{}))
+	})
+
+	t.Run("request with wait strategy", func(t *testing.T) {
+		assert.Equal(t, requestStrategy, provider.waitStrategy(ContainerRequest{WaitingFor: requestStrategy}))
+	})
+
+	t.Run("provider without default wait strategy", func(t *testing.T) {
+		noDefault := &DockerProvider{DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{}}}
+		assert.Nil(t, noDefault.waitStrategy(ContainerRequest{}))
+	})
+}
+
+func TestListSessionContainers(t *testing.T) {
+	ctx := context.Background()
+	// the containers are labelled with another session, so that the ones of the running tests are not listed
+	listedSessionID := uuid.New().String()
+
+	run := func(cmd ...string) Container {
+		req := ContainerRequest{
+			Image:      "docker.io/alpine:latest",
+			Cmd:        cmd,
+			SkipReaper: true,
+		}
+		require.NoError(t, req.WithSessionLabels(listedSessionID))
+
+		c, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType:     providerType,
+			ContainerRequest: req,
+			Started:          true,
+		})
+		require.NoError(t, err)
+		terminateContainerOnEnd(t, ctx, c)
+
+		return c
+	}
+
+	running := run("sleep", "60")
+	exited := run("echo", "exited")
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	// wait for the echo to exit
+	require.Eventually(t, func() bool {
+		state, err := exited.State(ctx)
+		return err == nil && !state.Running
+	}, 10*time.Second, 100*time.Millisecond)
+
+	containers, err := provider.ListSessionContainers(ctx, listedSessionID)
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+
+	byID := map[string]Container{}
+	for _, c := range containers {
+		assert.Equal(t, listedSessionID, c.SessionID())
+		byID[c.GetContainerID()] = c
+	}
+	require.Contains(t, byID, running.GetContainerID())
+	require.Contains(t, byID, exited.GetContainerID())
+	assert.True(t, byID[running.GetContainerID()].IsRunning())
+
+	// the handle of the exited container is still usable
+	logs, err := byID[exited.GetContainerID()].Logs(ctx)
+	require.NoError(t, err)
+	defer logs.Close()
+
+	content, err := io.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "exited")
+}
+
+func Test_ProviderWithDockerClientVersion(t *testing.T) {
+	t.Run("pinned by option", func(t *testing.T) {
+		t.Setenv("DOCKER_API_VERSION", "1.40")
+
+		provider, err := NewDockerProvider(WithLogger(TestLogger(t)), WithDockerClientVersion("1.39"))
+		require.NoError(t, err)
+		assert.Equal(t, "1.39", provider.Client().ClientVersion())
+	})
+
+	t.Run("pinned by the environment", func(t *testing.T) {
+		t.Setenv("DOCKER_API_VERSION", "1.40")
+
+		provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+		require.NoError(t, err)
+		assert.Equal(t, "1.40", provider.Client().ClientVersion())
+	})
+}
+
 func TestProviderHasConfig(t *testing.T) {
 	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
 	if err != nil {
@@ -2646,3 +4588,153 @@ func TestDockerProviderFindContainerByName(t *testing.T) {
 	require.NotNil(t, c)
 	assert.Contains(t, c.Names, c1Name)
 }
+
+func Test_DaemonHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerHost string
+		expected   string
+	}{
+		{
+			name:       "remote daemon over TCP",
+			dockerHost: "tcp://10.0.0.5:2375",
+			expected:   "10.0.0.5",
+		},
+		{
+			name:       "remote daemon over HTTPS",
+			dockerHost: "https://docker.example.com:2376",
+			expected:   "docker.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := client.NewClientWithOpts(client.WithHost(tt.dockerHost))
+			require.NoError(t, err)
+
+			p := &DockerProvider{client: cli}
+
+			host, err := p.DaemonHost(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, host)
+		})
+	}
+
+	t.Run("TC_HOST takes precedence", func(t *testing.T) {
+		t.Setenv("TC_HOST", "192.168.1.10")
+
+		cli, err := client.NewClientWithOpts(client.WithHost("tcp://10.0.0.5:2375"))
+		require.NoError(t, err)
+
+		p := &DockerProvider{client: cli}
+
+		host, err := p.DaemonHost(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "192.168.1.10", host)
+	})
+
+	t.Run("host is cached", func(t *testing.T) {
+		cli, err := client.NewClientWithOpts(client.WithHost("tcp://10.0.0.5:2375"))
+		require.NoError(t, err)
+
+		p := &DockerProvider{client: cli}
+
+		host, err := p.DaemonHost(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.5", host)
+
+		t.Setenv("TC_HOST", "192.168.1.10")
+
+		host, err = p.DaemonHost(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.5", host)
+	})
+}
+
+func TestContainerFromID(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:        nginxAlpineImage,
+			ExposedPorts: []string{nginxDefaultPort},
+			WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	t.Run("existing container", func(t *testing.T) {
+		c, err := provider.ContainerFromID(ctx, nginxC.GetContainerID())
+		require.NoError(t, err)
+
+		assert.Equal(t, nginxC.GetContainerID(), c.GetContainerID())
+		assert.Equal(t, nginxC.SessionID(), c.SessionID())
+		assert.True(t, c.IsRunning())
+
+		expectedPort, err := nginxC.MappedPort(ctx, nginxDefaultPort)
+		require.NoError(t, err)
+		port, err := c.MappedPort(ctx, nginxDefaultPort)
+		require.NoError(t, err)
+		assert.Equal(t, expectedPort, port)
+
+		code, _, err := c.Exec(ctx, []string{"nginx", "-t"})
+		require.NoError(t, err)
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("missing container", func(t *testing.T) {
+		_, err := provider.ContainerFromID(ctx, "missing-"+uuid.New().String())
+		require.Error(t, err)
+		assert.True(t, errdefs.IsNotFound(err))
+		assert.Contains(t, err.Error(), "does not exist")
+	})
+}
+
+// inspectContainerClient inspects the given containers, the other containers do not exist
+type inspectContainerClient struct {
+	client.APIClient
+	containers map[string]string
+}
+
+func (c *inspectContainerClient) ContainerInspectWithRaw(_ context.Context, id string, _ bool) (types.ContainerJSON, []byte, error) {
+	raw, ok := c.containers[id]
+	if !ok {
+		return types.ContainerJSON{}, nil, errdefs.NotFound(fmt.Errorf("no such container: %s", id))
+	}
+	var inspect types.ContainerJSON
+	err := json.Unmarshal([]byte(raw), &inspect)
+	return inspect, []byte(raw), err
+}
+
+func Test_ContainerFromID(t *testing.T) {
+	sessionID := uuid.New()
+	provider := &DockerProvider{
+		DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{Logger: Logger}},
+		client: &inspectContainerClient{containers: map[string]string{
+			"external": `{"Id":"0123456789abcdef","State":{"Running":true},"Config":{"Image":"docker.io/nginx:alpine"}}`,
+			"session":  `{"Id":"fedcba9876543210","State":{"Running":false},"Config":{"Image":"docker.io/alpine","Labels":{"` + TestcontainerLabelSessionID + `":"` + sessionID.String() + `"}}}`,
+		}},
+	}
+
+	c, err := provider.ContainerFromID(context.Background(), "external")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", c.GetContainerID())
+	assert.True(t, c.IsRunning())
+	assert.Equal(t, uuid.Nil.String(), c.SessionID())
+
+	c, err = provider.ContainerFromID(context.Background(), "session")
+	require.NoError(t, err)
+	assert.False(t, c.IsRunning())
+	assert.Equal(t, sessionID.String(), c.SessionID())
+
+	_, err = provider.ContainerFromID(context.Background(), "missing")
+	require.Error(t, err)
+	assert.True(t, errdefs.IsNotFound(err))
+	assert.Equal(t, "container missing does not exist: no such container: missing", err.Error())
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 func isDir(path string) (bool, error) {
@@ -39,16 +40,25 @@ func tarDir(src string, fileMode int64) (*bytes.Buffer, error) {
 	zr := gzip.NewWriter(buffer)
 	tw := tar.NewWriter(zr)
 
+	// the names in the TAR file are relative to the parent of the directory
+	parent := filepath.Dir(filepath.Clean(src))
+
 	// walk through every file in the folder
 	err := filepath.Walk(src, func(file string, fi os.FileInfo, errFn error) error {
 		if errFn != nil {
 			return fmt.Errorf("error traversing the file system: %w", errFn)
 		}
 
-		// if a symlink, skip file
+		// if a symlink, follow it to copy the file it points to. Symlinks to directories could create loops
 		if fi.Mode().Type() == os.ModeSymlink {
-			fmt.Printf(">> skipping symlink: %s\n", file)
-			return nil
+			target, err := os.Stat(file)
+			if err != nil {
+				return fmt.Errorf("error following symlink: %w", err)
+			}
+			if target.IsDir() {
+				return fmt.Errorf("symlink %s points to a directory, which cannot be copied", file)
+			}
+			fi = target
 		}
 
 		// generate tar header
@@ -57,9 +67,13 @@ func tarDir(src string, fileMode int64) (*bytes.Buffer, error) {
 			return fmt.Errorf("error getting file info header: %w", err)
 		}
 
-		// must provide real name
+		// must provide real name, relative to the parent of the directory so that it is extracted with its own name
 		// (see https://golang.org/src/archive/tar/common.go?#L626)
-		header.Name = filepath.ToSlash(file)
+		name, err := filepath.Rel(parent, file)
+		if err != nil {
+			return fmt.Errorf("error getting relative path: %w", err)
+		}
+		header.Name = filepath.ToSlash(name)
 		header.Mode = fileMode
 
 		// write header
@@ -126,3 +140,89 @@ func tarFile(fileContent []byte, basePath string, fileMode int64) (*bytes.Buffer
 
 	return buffer, nil
 }
+
+// tarSecrets compress the secrets as files of a secrets directory, using tar + gzip algorithms.
+// The files are only readable by their owner, as the secrets of Docker swarm
+func tarSecrets(secrets map[string]string) (*bytes.Buffer, error) {
+	buffer := &bytes.Buffer{}
+
+	zr := gzip.NewWriter(buffer)
+	tw := tar.NewWriter(zr)
+
+	if err := tw.WriteHeader(&tar.Header{Name: "secrets/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		return buffer, err
+	}
+
+	// the names are sorted, so that the archive is the same for the same secrets
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		hdr := &tar.Header{
+			Name: "secrets/" + name,
+			Mode: 0o400,
+			Size: int64(len(secrets[name])),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return buffer, err
+		}
+		if _, err := tw.Write([]byte(secrets[name])); err != nil {
+			return buffer, err
+		}
+	}
+
+	// produce tar
+	if err := tw.Close(); err != nil {
+		return buffer, fmt.Errorf("error closing tar file: %w", err)
+	}
+	// produce gzip
+	if err := zr.Close(); err != nil {
+		return buffer, fmt.Errorf("error closing gzip file: %w", err)
+	}
+
+	return buffer, nil
+}
+
+// tarReader streams the content of the reader as a single file, using tar + gzip algorithms.
+// The size must be the exact number of bytes provided by the reader, as it is written in the tar header
+// before the content is read.
+func tarReader(reader io.Reader, size int64, basePath string, fileMode int64) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		zr := gzip.NewWriter(pw)
+		tw := tar.NewWriter(zr)
+
+		hdr := &tar.Header{
+			Name: filepath.Base(basePath),
+			Mode: fileMode,
+			Size: size,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.CopyN(tw, reader, size); err != nil {
+			_ = pw.CloseWithError(fmt.Errorf("error copying %d bytes to tar file: %w", size, err))
+			return
+		}
+
+		// produce tar
+		if err := tw.Close(); err != nil {
+			_ = pw.CloseWithError(fmt.Errorf("error closing tar file: %w", err))
+			return
+		}
+		// produce gzip
+		if err := zr.Close(); err != nil {
+			_ = pw.CloseWithError(fmt.Errorf("error closing gzip file: %w", err))
+			return
+		}
+
+		_ = pw.Close()
+	}()
+
+	return pr
+}
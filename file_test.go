@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_IsDir(t *testing.T) {
@@ -88,6 +90,38 @@ func Test_TarDir(t *testing.T) {
 	}
 }
 
+func Test_TarDirWithNestedDirectoriesAndSymlinks(t *testing.T) {
+	// an absolute path, with a trailing separator
+	src := filepath.Join(t.TempDir(), "config") + string(filepath.Separator)
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "app.conf"), []byte("app"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "nested", "db.conf"), []byte("db"), 0644))
+	if err := os.Symlink("app.conf", filepath.Join(src, "link.conf")); err != nil {
+		t.Skipf("cannot create symlinks: %s", err)
+	}
+
+	buff, err := tarDir(src, 0755)
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, untar(tmpDir, bytes.NewReader(buff.Bytes())))
+
+	for path, expected := range map[string]string{
+		filepath.Join("config", "app.conf"):          "app",
+		filepath.Join("config", "nested", "db.conf"): "db",
+		filepath.Join("config", "link.conf"):         "app", // the symlink is followed
+	} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, path))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+
+	// symlinks to directories are not followed
+	require.NoError(t, os.Symlink("nested", filepath.Join(src, "link")))
+	_, err = tarDir(src, 0755)
+	require.Error(t, err)
+}
+
 func Test_TarFile(t *testing.T) {
 	b, err := os.ReadFile(filepath.Join(".", "testresources", "Dockerfile"))
 	if err != nil {
@@ -112,6 +146,64 @@ func Test_TarFile(t *testing.T) {
 	assert.Equal(t, b, untarBytes)
 }
 
+func Test_TarReader(t *testing.T) {
+	content := bytes.Repeat([]byte("testcontainers"), 1024)
+
+	t.Run("streams the content of the reader", func(t *testing.T) {
+		stream := tarReader(bytes.NewReader(content), int64(len(content)), "/tmp/stream.txt", 0755)
+		defer stream.Close()
+
+		tmpDir := t.TempDir()
+		err := untar(tmpDir, stream)
+		require.NoError(t, err)
+
+		untarBytes, err := os.ReadFile(filepath.Join(tmpDir, "stream.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, untarBytes)
+	})
+
+	t.Run("fails when the reader is shorter than the size", func(t *testing.T) {
+		stream := tarReader(bytes.NewReader(content), int64(len(content)+1), "/tmp/stream.txt", 0755)
+		defer stream.Close()
+
+		_, err := io.Copy(io.Discard, stream)
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func Test_TarSecrets(t *testing.T) {
+	buff, err := tarSecrets(map[string]string{"db_password": "s3cr3t", "api_key": "0123456789"})
+	require.NoError(t, err)
+
+	zr, err := gzip.NewReader(buff)
+	require.NoError(t, err)
+	tr := tar.NewReader(zr)
+
+	type entry struct {
+		name    string
+		mode    int64
+		content string
+	}
+	var entries []entry
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, entry{name: header.Name, mode: header.Mode, content: string(content)})
+	}
+
+	assert.Equal(t, []entry{
+		{name: "secrets/", mode: 0o755},
+		{name: "secrets/api_key", mode: 0o400, content: "0123456789"},
+		{name: "secrets/db_password", mode: 0o400, content: "s3cr3t"},
+	}, entries)
+}
+
 // untar takes a destination path and a reader; a tar reader loops over the tarfile
 // creating the file structure at 'dst' along the way, and writing any files
 func untar(dst string, r io.Reader) error {
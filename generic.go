@@ -10,6 +10,9 @@ import (
 var (
 	reuseContainerMx  sync.Mutex
 	ErrReuseEmptyName = errors.New("with reuse option a container name mustn't be empty")
+	// ErrReuseFileReader is returned when a reused container has a file whose reader cannot be rewound,
+	// as its content must be hashed to know whether the container can be reused
+	ErrReuseFileReader = errors.New("with reuse option the reader of a file must implement io.Seeker")
 )
 
 // GenericContainerRequest represents parameters to a generic container
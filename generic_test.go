@@ -96,3 +96,39 @@ func TestGenericReusableContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericReusableContainerIsCreatedAgainWhenTheRequestChanges(t *testing.T) {
+	ctx := context.Background()
+
+	reuse := func(env map[string]string) Container {
+		c, err := GenericContainer(ctx, GenericContainerRequest{
+			ProviderType: providerType,
+			ContainerRequest: ContainerRequest{
+				Image:        nginxAlpineImage,
+				ExposedPorts: []string{nginxDefaultPort},
+				Env:          env,
+				Name:         reusableContainerName + "_hash",
+			},
+			Started: true,
+			Reuse:   true,
+		})
+		require.NoError(t, err)
+
+		return c
+	}
+
+	n1 := reuse(map[string]string{"FOO": "bar"})
+
+	inspect, err := n1.(*DockerContainer).inspectContainer(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, inspect.Config.Labels[TestcontainerLabelHash])
+	require.NotContains(t, inspect.Config.Labels, TestcontainerLabelSessionID, "a reused container must outlive the session")
+
+	n2 := reuse(map[string]string{"FOO": "bar"})
+	require.Equal(t, n1.GetContainerID(), n2.GetContainerID())
+
+	// the previous container was removed, so only the new one must be terminated
+	n3 := reuse(map[string]string{"FOO": "baz"})
+	terminateContainerOnEnd(t, ctx, n3)
+	require.NotEqual(t, n1.GetContainerID(), n3.GetContainerID())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -334,3 +335,119 @@ func TestContainerLogsShouldBeWithoutStreamHeader(t *testing.T) {
 	}
 	assert.Equal(t, "0", strings.TrimSpace(string(b)))
 }
+
+func TestContainerLogsWithTail(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:      "alpine:latest",
+		Cmd:        []string{"sh", "-c", "for i in 1 2 3 4 5; do echo \"$(date +%s) line $i\"; done"},
+		WaitingFor: wait.ForExit(),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	r, err := container.LogsWithOptions(ctx, WithTail(2))
+	require.NoError(t, err)
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], "line 4"))
+	assert.True(t, strings.HasSuffix(lines[1], "line 5"))
+
+	// all the logs were written before now
+	r, err = container.LogsWithOptions(ctx, WithSince(time.Now().Add(time.Minute)))
+	require.NoError(t, err)
+	defer r.Close()
+
+	b, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(b)))
+}
+
+// logsRecorderClient records the options of the logs requests, and returns no logs
+type logsRecorderClient struct {
+	client.APIClient
+	options types.ContainerLogsOptions
+}
+
+func (c *logsRecorderClient) ContainerLogs(_ context.Context, _ string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	c.options = options
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func Test_LogsWithOptions(t *testing.T) {
+	since := time.Unix(1600000000, 500)
+	until := time.Unix(1600000060, 0)
+
+	recorder := &logsRecorderClient{}
+	c := &DockerContainer{ID: "0123456789abcdef", provider: &DockerProvider{client: recorder}}
+
+	r, err := c.LogsWithOptions(context.Background(), WithSince(since), WithUntil(until), WithTail(10))
+	require.NoError(t, err)
+	_ = r.Close()
+
+	assert.Equal(t, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      "1600000000.000000500",
+		Until:      "1600000060.000000000",
+		Tail:       "10",
+	}, recorder.options)
+
+	r, err = c.LogsWithOptions(context.Background(), WithTail(-1))
+	require.NoError(t, err)
+	_ = r.Close()
+
+	assert.Equal(t, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}, recorder.options, "all the lines are returned")
+}
+
+func Test_RemoveConsumer(t *testing.T) {
+	c := &DockerContainer{}
+
+	first := &TestLogConsumer{Msgs: []string{}}
+	second := &TestLogConsumer{Msgs: []string{}}
+	c.FollowOutput(first)
+	c.FollowOutput(second)
+
+	c.RemoveConsumer(first)
+	assert.Equal(t, []LogConsumer{second}, c.logConsumers())
+
+	// removing a consumer which is not following the output is a no-op
+	c.RemoveConsumer(first)
+	assert.Equal(t, []LogConsumer{second}, c.logConsumers())
+
+	c.RemoveConsumer(second)
+	assert.Empty(t, c.logConsumers())
+}
+
+func Test_RemoveConsumerWhileLogsAreForwarded(t *testing.T) {
+	c := &DockerContainer{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// as the log producer does for every log
+		for i := 0; i < 1000; i++ {
+			for _, consumer := range c.logConsumers() {
+				consumer.Accept(Log{LogType: StdoutLog, Content: []byte("log")})
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		consumer := &TestLogConsumer{Msgs: []string{}}
+		c.FollowOutput(consumer)
+		c.RemoveConsumer(consumer)
+	}
+	<-done
+
+	assert.Empty(t, c.logConsumers())
+}
@@ -73,3 +73,8 @@ func (t testLogger) Printf(format string, v ...interface{}) {
 	t.Helper()
 	t.Logf(format, v...)
 }
+
+// noopLogger is a Logging implementation that discards all the messages
+type noopLogger struct{}
+
+func (noopLogger) Printf(format string, v ...interface{}) {}
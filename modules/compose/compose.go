@@ -47,6 +47,10 @@ type stackUpOptions struct {
 	Recreate string
 	// RecreateDependencies define the strategy to apply on dependencies services
 	RecreateDependencies string
+	// Env defines the environment variables interpolated into the stack files
+	Env map[string]string
+	// Profiles defines the profiles of the services to start
+	Profiles []string
 	// Project is the compose project used to define this app. Might be nil if user ran command just with project name
 	Project *types.Project
 }
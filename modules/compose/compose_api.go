@@ -42,6 +42,23 @@ func RunServices(serviceNames ...string) StackUpOption {
 	})
 }
 
+// WithEnv sets environment variables that are interpolated into the stack files when the stack is started,
+// e.g. ${VAR} expressions. They take precedence over the ones set with ComposeStack.WithEnv
+func WithEnv(env map[string]string) StackUpOption {
+	return stackUpOptionFunc(func(o *stackUpOptions) {
+		o.Env = env
+	})
+}
+
+// WithProfiles enables the services of the given profiles, comparable to 'docker-compose --profile'.
+// Without profiles all the services are started, otherwise only the services without profile
+// and the ones of the given profiles are
+func WithProfiles(profiles ...string) StackUpOption {
+	return stackUpOptionFunc(func(o *stackUpOptions) {
+		o.Profiles = profiles
+	})
+}
+
 // IgnoreOrphans - Ignore legacy containers for services that are not defined in the project
 type IgnoreOrphans bool
 
@@ -135,6 +152,10 @@ type dockerCompose struct {
 	// compiled compose project
 	// can be nil if the stack wasn't started yet
 	project *types.Project
+
+	// termination signal of the connection to the reaper of the session, held from Up to Down
+	// so that the reaper removes the stack only once it is down, or once the tests exit
+	terminationSignal chan bool
 }
 
 func (d *dockerCompose) ServiceContainer(ctx context.Context, svcName string) (*testcontainers.DockerContainer, error) {
@@ -165,29 +186,49 @@ func (d *dockerCompose) Down(ctx context.Context, opts ...StackDownOption) error
 		opts[i].applyToStackDown(&options)
 	}
 
-	return d.composeService.Down(ctx, d.name, options.DownOptions)
+	if err := d.composeService.Down(ctx, d.name, options.DownOptions); err != nil {
+		return err
+	}
+
+	select {
+	// close reaper connection if it was opened
+	case d.terminationSignal <- true:
+	default:
+	}
+	d.terminationSignal = nil
+
+	return nil
 }
 
 func (d *dockerCompose) Up(ctx context.Context, opts ...StackUpOption) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
-	d.project, err = d.compileProject()
-	if err != nil {
-		return err
-	}
-
 	upOptions := stackUpOptions{
-		Services:             d.project.ServiceNames(),
 		Recreate:             api.RecreateDiverged,
 		RecreateDependencies: api.RecreateDiverged,
-		Project:              d.project,
 	}
 
 	for i := range opts {
 		opts[i].applyToStackUp(&upOptions)
 	}
 
+	// the options are applied first, as the environment and the profiles are needed to compile the project
+	d.project, err = d.compileProject(upOptions.Env, upOptions.Profiles)
+	if err != nil {
+		return err
+	}
+
+	// the reaper is connected before the stack is created, so that its resources are removed if the tests exit
+	if err := d.connectReaper(ctx); err != nil {
+		return err
+	}
+
+	upOptions.Project = d.project
+	if len(upOptions.Services) == 0 {
+		upOptions.Services = d.project.ServiceNames()
+	}
+
 	if len(upOptions.Services) != len(d.project.Services) {
 		sort.Strings(upOptions.Services)
 
@@ -265,6 +306,32 @@ func (d *dockerCompose) WithOsEnv() ComposeStack {
 	return d
 }
 
+// connectReaper connects to the reaper of the session, which removes the resources labelled with the session ID
+// once the connection is closed, unless the stack already holds a connection
+func (d *dockerCompose) connectReaper(ctx context.Context) error {
+	if d.terminationSignal != nil {
+		return nil
+	}
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return err
+	}
+
+	r, err := provider.SessionReaper(ctx)
+	if err != nil {
+		return err
+	}
+
+	termSignal, err := r.Connect()
+	if err != nil {
+		return fmt.Errorf("%w: connecting to reaper failed", err)
+	}
+	d.terminationSignal = termSignal
+
+	return nil
+}
+
 func (d *dockerCompose) lookupContainer(ctx context.Context, svcName string) (*testcontainers.DockerContainer, error) {
 	if container, ok := d.containers[svcName]; ok {
 		return container, nil
@@ -302,12 +369,12 @@ func (d *dockerCompose) lookupContainer(ctx context.Context, svcName string) (*t
 	return container, nil
 }
 
-func (d *dockerCompose) compileProject() (*types.Project, error) {
-	const nameAndDefaultConfigPath = 2
-	projectOptions := make([]cli.ProjectOptionsFn, len(d.projectOptions), len(d.projectOptions)+nameAndDefaultConfigPath)
+func (d *dockerCompose) compileProject(env map[string]string, profiles []string) (*types.Project, error) {
+	const nameDefaultConfigPathAndEnv = 3
+	projectOptions := make([]cli.ProjectOptionsFn, len(d.projectOptions), len(d.projectOptions)+nameDefaultConfigPathAndEnv)
 
 	copy(projectOptions, d.projectOptions)
-	projectOptions = append(projectOptions, cli.WithName(d.name), cli.WithDefaultConfigPath)
+	projectOptions = append(projectOptions, cli.WithName(d.name), cli.WithDefaultConfigPath, withEnvOverride(env))
 
 	compiledOptions, err := cli.NewProjectOptions(d.configs, projectOptions...)
 	if err != nil {
@@ -319,6 +386,10 @@ func (d *dockerCompose) compileProject() (*types.Project, error) {
 		return nil, err
 	}
 
+	if len(profiles) > 0 {
+		proj.ApplyProfiles(profiles)
+	}
+
 	for i, s := range proj.Services {
 		s.CustomLabels = map[string]string{
 			api.ProjectLabel:     proj.Name,
@@ -331,12 +402,50 @@ func (d *dockerCompose) compileProject() (*types.Project, error) {
 		if compiledOptions.EnvFile != "" {
 			s.CustomLabels[api.EnvironmentFileLabel] = compiledOptions.EnvFile
 		}
+		for k, v := range sessionLabels() {
+			s.CustomLabels[k] = v
+		}
 		proj.Services[i] = s
 	}
 
+	// the networks and volumes created by the stack are labelled as well, so that the reaper removes them with the containers
+	for name, n := range proj.Networks {
+		if n.External.External {
+			continue
+		}
+		if n.Labels == nil {
+			n.Labels = types.Labels{}
+		}
+		for k, v := range sessionLabels() {
+			n.Labels[k] = v
+		}
+		proj.Networks[name] = n
+	}
+
+	for name, v := range proj.Volumes {
+		if v.External.External {
+			continue
+		}
+		if v.Labels == nil {
+			v.Labels = types.Labels{}
+		}
+		for k, val := range sessionLabels() {
+			v.Labels[k] = val
+		}
+		proj.Volumes[name] = v
+	}
+
 	return proj, nil
 }
 
+// sessionLabels returns the labels the reaper of the current test session filters on
+func sessionLabels() map[string]string {
+	return map[string]string{
+		testcontainers.TestcontainerLabel:          "true",
+		testcontainers.TestcontainerLabelSessionID: testcontainers.SessionID(),
+	}
+}
+
 func withEnv(env map[string]string) func(*cli.ProjectOptions) error {
 	return func(options *cli.ProjectOptions) error {
 		for k, v := range env {
@@ -351,6 +460,17 @@ func withEnv(env map[string]string) func(*cli.ProjectOptions) error {
 	}
 }
 
+// withEnvOverride sets the environment variables, overriding the ones already set
+func withEnvOverride(env map[string]string) func(*cli.ProjectOptions) error {
+	return func(options *cli.ProjectOptions) error {
+		for k, v := range env {
+			options.Environment[k] = v
+		}
+
+		return nil
+	}
+}
+
 func makeClient(*command.DockerCli) (client.APIClient, error) {
 	dockerClient, _, _, err := testcontainers.NewDockerClient()
 	if err != nil {
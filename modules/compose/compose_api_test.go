@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
@@ -26,6 +27,28 @@ func TestDockerComposeAPI(t *testing.T) {
 	assert.NoError(t, compose.Up(ctx, Wait(true)), "compose.Up()")
 }
 
+func TestDockerComposeAPIWithSessionLabels(t *testing.T) {
+	compose, err := NewDockerCompose("./testresources/docker-compose-simple.yml")
+	assert.NoError(t, err, "NewDockerCompose()")
+
+	t.Cleanup(func() {
+		assert.NoError(t, compose.Down(context.Background(), RemoveOrphans(true), RemoveImagesLocal), "compose.Down()")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	assert.NoError(t, compose.Up(ctx, Wait(true)), "compose.Up()")
+
+	container, err := compose.ServiceContainer(ctx, "nginx")
+	assert.NoError(t, err, "compose.ServiceContainer()")
+
+	inspect, err := compose.dockerClient.ContainerInspect(ctx, container.GetContainerID())
+	assert.NoError(t, err, "ContainerInspect()")
+	assert.Equal(t, "true", inspect.Config.Labels[testcontainers.TestcontainerLabel])
+	assert.Equal(t, testcontainers.SessionID(), inspect.Config.Labels[testcontainers.TestcontainerLabelSessionID])
+}
+
 func TestDockerComposeAPIStrategyForInvalidService(t *testing.T) {
 	compose, err := NewDockerCompose("./testresources/docker-compose-simple.yml")
 	assert.NoError(t, err, "NewDockerCompose()")
@@ -308,6 +331,61 @@ func TestDockerComposeAPIWithEnvironment(t *testing.T) {
 	assertContainerEnvironmentVariables(t, identifier.String(), "nginx", present, absent)
 }
 
+func TestDockerComposeAPIWithUpEnvironmentAndProfiles(t *testing.T) {
+	identifier := testNameHash(t.Name())
+
+	compose, err := NewDockerComposeWith(WithStackFiles("./testresources/docker-compose-profiles.yml"), identifier)
+	assert.NoError(t, err, "NewDockerCompose()")
+
+	t.Cleanup(func() {
+		assert.NoError(t, compose.Down(context.Background(), RemoveOrphans(true), RemoveImagesLocal), "compose.Down()")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	err = compose.Up(ctx, Wait(true), WithEnv(map[string]string{
+		"NGINX_TAG": "stable-alpine",
+		"bar":       "BAR",
+	}), WithProfiles("debug"))
+	assert.NoError(t, err, "compose.Up()")
+
+	serviceNames := compose.Services()
+
+	assert.Equal(t, 2, len(serviceNames))
+	assert.Contains(t, serviceNames, "nginx")
+	assert.Contains(t, serviceNames, "debug")
+
+	present := map[string]string{
+		"bar": "BAR",
+	}
+	absent := map[string]string{}
+	assertContainerEnvironmentVariables(t, identifier.String(), "nginx", present, absent)
+}
+
+func Test_CompileProjectWithEnvironmentAndProfiles(t *testing.T) {
+	compose := &dockerCompose{
+		name:    "profiles",
+		configs: []string{"./testresources/docker-compose-profiles.yml"},
+	}
+
+	proj, err := compose.compileProject(map[string]string{"NGINX_TAG": "stable-alpine"}, nil)
+	assert.NoError(t, err, "compileProject()")
+	assert.ElementsMatch(t, []string{"nginx", "debug"}, proj.ServiceNames(), "all the services are enabled without profiles")
+
+	nginx, err := proj.GetService("nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker.io/nginx:stable-alpine", nginx.Image)
+
+	proj, err = compose.compileProject(nil, []string{"other"})
+	assert.NoError(t, err, "compileProject()")
+	assert.Equal(t, []string{"nginx"}, proj.ServiceNames(), "the services of other profiles are disabled")
+
+	proj, err = compose.compileProject(nil, []string{"debug"})
+	assert.NoError(t, err, "compileProject()")
+	assert.ElementsMatch(t, []string{"nginx", "debug"}, proj.ServiceNames())
+}
+
 func TestDockerComposeAPIWithMultipleComposeFiles(t *testing.T) {
 	composeFiles := ComposeStackFiles{
 		"testresources/docker-compose-simple.yml",
@@ -47,10 +47,15 @@ func (GenericBindMountSource) Type() MountType {
 }
 
 // GenericVolumeMountSource implements ContainerMountSource and represents a volume mount
+// The named volume is created by Docker when it does not exist yet, and it is kept after the container is removed,
+// e.g. to persist a seeded database between test runs
 type GenericVolumeMountSource struct {
 	// Name refers to the name of the volume to be mounted
 	// the same volume might be mounted to multiple locations within a single container
 	Name string
+	// Reaped labels the volume with the session of the container when it is created by the mount,
+	// so that it is removed by the reaper at the end of the session
+	Reaped bool
 }
 
 func (s GenericVolumeMountSource) Source() string {
@@ -170,7 +170,30 @@ func TestContainerMounts_PrepareMounts(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			assert.Equalf(t, tt.want, mapToDockerMounts(tt.mounts), "PrepareMounts()")
+			assert.Equalf(t, tt.want, mapToDockerMounts(tt.mounts, nil), "PrepareMounts()")
 		})
 	}
 }
+
+func TestContainerMounts_PrepareMountsWithReapedVolume(t *testing.T) {
+	labels := sessionLabels("session")
+	mounts := ContainerMounts{
+		{Source: GenericVolumeMountSource{Name: "reaped", Reaped: true}, Target: "/reaped"},
+		{Source: GenericVolumeMountSource{Name: "kept"}, Target: "/kept"},
+	}
+
+	want := []mount.Mount{
+		{
+			Type:          mount.TypeVolume,
+			Source:        "reaped",
+			Target:        "/reaped",
+			VolumeOptions: &mount.VolumeOptions{Labels: labels},
+		},
+		{
+			Type:   mount.TypeVolume,
+			Source: "kept",
+			Target: "/kept",
+		},
+	}
+	assert.Equal(t, want, mapToDockerMounts(mounts, labels))
+}
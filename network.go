@@ -2,6 +2,8 @@ package testcontainers
 
 import (
 	"context"
+	"fmt"
+	"net"
 
 	"github.com/docker/docker/api/types/network"
 
@@ -16,6 +18,7 @@ type NetworkProvider interface {
 
 // Network allows getting info about a single network instance
 type Network interface {
+	GetNetworkID() string         // get the network id from the provider
 	Remove(context.Context) error // removes the network
 }
 
@@ -38,9 +41,56 @@ type NetworkRequest struct {
 	Name           string
 	Labels         map[string]string
 	Attachable     bool
-	IPAM           *network.IPAM
+	IPAM           *network.IPAM // e.g. to set the subnet and the gateway of the network, validated on creation
 
 	SkipReaper    bool              // indicates whether we skip setting up a reaper for this
 	ReaperImage   string            // Deprecated: use WithImageName ContainerOption instead. Alternative reaper registry
 	ReaperOptions []ContainerOption // Reaper options to use for this network
 }
+
+// Validate ensures that the NetworkRequest does not have invalid parameters configured to it
+func (r *NetworkRequest) Validate() error {
+	return r.validateIPAM()
+}
+
+func (r *NetworkRequest) validateIPAM() error {
+	if r.IPAM == nil {
+		return nil
+	}
+
+	for _, config := range r.IPAM.Config {
+		if config.Subnet == "" {
+			continue
+		}
+
+		_, subnet, err := net.ParseCIDR(config.Subnet)
+		if err != nil {
+			return fmt.Errorf("%w: subnet %s is not in the CIDR notation", ErrInvalidIPAM, config.Subnet)
+		}
+
+		if config.Gateway != "" {
+			gateway := net.ParseIP(config.Gateway)
+			if gateway == nil {
+				return fmt.Errorf("%w: gateway %s is not an IP address", ErrInvalidIPAM, config.Gateway)
+			}
+			if !subnet.Contains(gateway) {
+				return fmt.Errorf("%w: gateway %s is not in subnet %s", ErrInvalidIPAM, config.Gateway, config.Subnet)
+			}
+		}
+
+		if config.IPRange != "" {
+			_, ipRange, err := net.ParseCIDR(config.IPRange)
+			if err != nil {
+				return fmt.Errorf("%w: IP range %s is not in the CIDR notation", ErrInvalidIPAM, config.IPRange)
+			}
+
+			rangeOnes, _ := ipRange.Mask.Size()
+			subnetOnes, _ := subnet.Mask.Size()
+			if !subnet.Contains(ipRange.IP) || rangeOnes < subnetOnes {
+				return fmt.Errorf("%w: IP range %s is not in subnet %s", ErrInvalidIPAM, config.IPRange, config.Subnet)
+			}
+		}
+	}
+
+	return nil
+}
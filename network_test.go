@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -174,3 +179,288 @@ func Test_MultipleContainersInTheNewNetwork(t *testing.T) {
 	fmt.Println(postgres.GetContainerID())
 	fmt.Println(rabbitmq.GetContainerID())
 }
+
+func Test_NetworkRequestValidation(t *testing.T) {
+	testTable := []struct {
+		name          string
+		ipam          *network.IPAM
+		expectedError string
+	}{
+		{
+			name: "no IPAM configuration",
+		},
+		{
+			name: "subnet with gateway and IP range",
+			ipam: &network.IPAM{
+				Config: []network.IPAMConfig{
+					{Subnet: "10.1.1.0/24", Gateway: "10.1.1.254", IPRange: "10.1.1.0/25"},
+				},
+			},
+		},
+		{
+			name: "subnet not in the CIDR notation",
+			ipam: &network.IPAM{
+				Config: []network.IPAMConfig{{Subnet: "10.1.1.0"}},
+			},
+			expectedError: "invalid IPAM configuration: subnet 10.1.1.0 is not in the CIDR notation",
+		},
+		{
+			name: "gateway out of the subnet",
+			ipam: &network.IPAM{
+				Config: []network.IPAMConfig{{Subnet: "10.1.1.0/24", Gateway: "10.1.2.1"}},
+			},
+			expectedError: "invalid IPAM configuration: gateway 10.1.2.1 is not in subnet 10.1.1.0/24",
+		},
+		{
+			name: "IP range larger than the subnet",
+			ipam: &network.IPAM{
+				Config: []network.IPAMConfig{{Subnet: "10.1.1.0/24", IPRange: "10.1.0.0/16"}},
+			},
+			expectedError: "invalid IPAM configuration: IP range 10.1.0.0/16 is not in subnet 10.1.1.0/24",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := NetworkRequest{Name: "test-network", IPAM: testCase.ipam}
+
+			err := req.Validate()
+			if testCase.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidIPAM)
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_ContainerAddressInNetworkWithFixedSubnet(t *testing.T) {
+	ctx := context.Background()
+	networkName := "test-network-with-fixed-subnet"
+	_, subnet, _ := net.ParseCIDR("10.1.2.0/24")
+
+	n, err := GenericNetwork(ctx, GenericNetworkRequest{
+		NetworkRequest: NetworkRequest{
+			Name:           networkName,
+			CheckDuplicate: true,
+			IPAM: &network.IPAM{
+				Config: []network.IPAMConfig{
+					{Subnet: subnet.String(), Gateway: "10.1.2.254"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = n.Remove(ctx)
+	}()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:    nginxAlpineImage,
+			Networks: []string{networkName},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	inspect, err := nginxC.(*DockerContainer).inspectContainer(ctx)
+	require.NoError(t, err)
+	require.Contains(t, inspect.NetworkSettings.Networks, networkName)
+
+	ip := inspect.NetworkSettings.Networks[networkName].IPAddress
+	assert.True(t, subnet.Contains(net.ParseIP(ip)), "%s is not in subnet %s", ip, subnet)
+}
+
+func Test_ContainerWithStaticIPv4Address(t *testing.T) {
+	ctx := context.Background()
+	networkName := "test-network-with-static-ip"
+
+	n, err := GenericNetwork(ctx, GenericNetworkRequest{
+		NetworkRequest: NetworkRequest{
+			Name:           networkName,
+			CheckDuplicate: true,
+			IPAM: &network.IPAM{
+				Config: []network.IPAMConfig{{Subnet: "10.1.3.0/24"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = n.Remove(ctx)
+	}()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:         nginxAlpineImage,
+			Networks:      []string{networkName},
+			IPv4Addresses: map[string]string{networkName: "10.1.3.42"},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	inspect, err := nginxC.(*DockerContainer).inspectContainer(ctx)
+	require.NoError(t, err)
+	require.Contains(t, inspect.NetworkSettings.Networks, networkName)
+	assert.Equal(t, "10.1.3.42", inspect.NetworkSettings.Networks[networkName].IPAddress)
+
+	_, err = GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:         nginxAlpineImage,
+			Networks:      []string{networkName},
+			IPv4Addresses: map[string]string{networkName: "10.1.4.42"},
+		},
+	})
+	require.ErrorIs(t, err, ErrInvalidIPAddress)
+}
+
+func Test_ContainerConnectsAndDisconnectsFromNetwork(t *testing.T) {
+	ctx := context.Background()
+	networkName := "test-network-partition"
+
+	n, err := GenericNetwork(ctx, GenericNetworkRequest{
+		NetworkRequest: NetworkRequest{
+			Name:           networkName,
+			CheckDuplicate: true,
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = n.Remove(ctx)
+	}()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:          nginxAlpineImage,
+			Networks:       []string{networkName},
+			NetworkAliases: map[string][]string{networkName: {"nginx"}},
+			WaitingFor:     wait.ForListeningPort(nginxDefaultPort),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	client, err := GenericContainer(ctx, GenericContainerRequest{
+		ContainerRequest: ContainerRequest{
+			Image:    "docker.io/alpine:latest",
+			Cmd:      []string{"sleep", "60"},
+			Networks: []string{networkName},
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, client)
+
+	reachable := func() bool {
+		code, _, err := client.Exec(ctx, []string{"wget", "-q", "-T", "2", "-O", "/dev/null", "http://nginx"})
+		require.NoError(t, err)
+		return code == 0
+	}
+
+	require.True(t, reachable())
+
+	require.NoError(t, nginxC.DisconnectFromNetwork(ctx, networkName))
+	assert.False(t, reachable(), "nginx should be unreachable once disconnected")
+
+	require.NoError(t, nginxC.ConnectToNetwork(ctx, networkName, "nginx"))
+	assert.True(t, reachable(), "nginx should be reachable again once reconnected")
+}
+
+func Test_NetworkIsLabelledWithTheSession(t *testing.T) {
+	ctx := context.Background()
+
+	nw, err := GenericNetwork(ctx, GenericNetworkRequest{
+		NetworkRequest: NetworkRequest{
+			Name:   "test-network-labels-" + uuid.New().String(),
+			Labels: map[string]string{"team": "storage"},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, nw.Remove(ctx))
+	})
+
+	provider, err := NewDockerProvider()
+	require.NoError(t, err)
+
+	resource, err := provider.client.NetworkInspect(ctx, nw.GetNetworkID(), types.NetworkInspectOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "storage", resource.Labels["team"])
+	assert.Equal(t, "true", resource.Labels[TestcontainerLabel])
+	assert.Equal(t, sessionID().String(), resource.Labels[TestcontainerLabelSessionID])
+}
+
+// networkCreateRecorderClient records the created networks
+type networkCreateRecorderClient struct {
+	client.APIClient
+	created types.NetworkCreate
+}
+
+func (c *networkCreateRecorderClient) NetworkCreate(_ context.Context, _ string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	c.created = options
+	return types.NetworkCreateResponse{ID: "network"}, nil
+}
+
+func Test_NetworkWithDefaultLabels(t *testing.T) {
+	recorder := &networkCreateRecorderClient{}
+	provider := &DockerProvider{
+		DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{
+			Logger:         TestLogger(t),
+			DefaultNetwork: Bridge,
+			DefaultLabels:  map[string]string{"build_id": "42", "team": "default"},
+		}},
+		client: recorder,
+	}
+
+	labels := map[string]string{"team": "storage"}
+	nw, err := provider.CreateNetwork(context.Background(), NetworkRequest{
+		Name:       "my-network",
+		Labels:     labels,
+		SkipReaper: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "network", nw.GetNetworkID())
+	assert.Equal(t, map[string]string{"build_id": "42", "team": "storage"}, recorder.created.Labels)
+	assert.Equal(t, map[string]string{"team": "storage"}, labels, "the labels of the request should not be modified")
+
+	provider.DefaultLabels = map[string]string{TestcontainerLabel: "false"}
+	_, err = provider.CreateNetwork(context.Background(), NetworkRequest{Name: "my-network", SkipReaper: true})
+	require.ErrorIs(t, err, ErrReservedLabel)
+}
+
+func Test_NetworkWithConflictingSessionLabel(t *testing.T) {
+	ryuk := newFakeRyuk(t)
+	session := sessionID().String()
+	reapers[session] = &Reaper{SessionID: session, Endpoint: ryuk.Endpoint()}
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	recorder := &networkCreateRecorderClient{}
+	provider := &DockerProvider{
+		DockerProviderOptions: &DockerProviderOptions{GenericProviderOptions: &GenericProviderOptions{
+			Logger:         TestLogger(t),
+			DefaultNetwork: Bridge,
+		}},
+		client: recorder,
+	}
+
+	_, err := provider.CreateNetwork(context.Background(), NetworkRequest{
+		Name:   "my-network",
+		Labels: map[string]string{TestcontainerLabelSessionID: "another-session"},
+	})
+	require.ErrorIs(t, err, ErrReservedLabel)
+
+	// the rejected request must not leave a connection to the reaper behind
+	select {
+	case filter := <-ryuk.filters:
+		t.Fatalf("the reaper should not be connected, it received %q", filter)
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Empty(t, recorder.created.Labels, "the network should not be created")
+}
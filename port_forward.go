@@ -0,0 +1,121 @@
+package testcontainers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+// PortForward forwards a local address to the given TCP port of the container, e.g. to reach a container of a remote
+// Docker daemon whose mapped ports cannot be reached from the host running the tests. Each connection to the local
+// address is relayed through a command executed in the container, socat or else nc, so one of them must be installed
+// in the image, and the relay is much slower than a mapped port. The forward lasts until the returned function is
+// called or the context is done
+func (c *DockerContainer) PortForward(ctx context.Context, containerPort nat.Port) (string, func(), error) {
+	if containerPort.Proto() != "tcp" {
+		return "", nil, fmt.Errorf("cannot forward port %s: only TCP ports can be forwarded", containerPort)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot listen to forward port %s: %w", containerPort, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				// the listener is closed when the forward is stopped
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.forward(ctx, conn, containerPort)
+			}()
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			_ = listener.Close()
+			wg.Wait()
+		})
+	}
+
+	return listener.Addr().String(), stop, nil
+}
+
+// forward relays the connection to the port of the container, until either side closes it or the context is done
+func (c *DockerContainer) forward(ctx context.Context, conn net.Conn, containerPort nat.Port) {
+	defer conn.Close()
+
+	cli := c.provider.client
+	response, err := cli.ContainerExecCreate(ctx, c.ID, types.ExecConfig{
+		Cmd:          portForwardCmd(containerPort),
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		c.logger.Printf("cannot forward port %s of container %s: %v", containerPort, c.ID[:12], err)
+		return
+	}
+
+	hijack, err := cli.ContainerExecAttach(ctx, response.ID, types.ExecStartCheck{})
+	if err != nil {
+		c.logger.Printf("cannot forward port %s of container %s: %v", containerPort, c.ID[:12], err)
+		return
+	}
+	defer hijack.Close()
+
+	go func() {
+		// the input is copied until the local side closes the connection, or the relay exits
+		_, _ = io.Copy(hijack.Conn, conn)
+		_ = hijack.CloseWrite()
+	}()
+
+	// the output is multiplexed, as no TTY is allocated, until the relay exits
+	done := make(chan struct{})
+	var stderr bytes.Buffer
+	go func() {
+		defer close(done)
+		_, _ = stdcopy.StdCopy(conn, &stderr, hijack.Reader)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+		if stderr.Len() > 0 {
+			c.logger.Printf("cannot forward port %s of container %s: %s", containerPort, c.ID[:12], stderr.String())
+		}
+	}
+}
+
+// portForwardCmd returns the command relaying its standard input and output to the port of the container
+func portForwardCmd(containerPort nat.Port) []string {
+	port := containerPort.Port()
+	return []string{"sh", "-c", fmt.Sprintf(
+		"if command -v socat >/dev/null 2>&1; then exec socat - TCP:127.0.0.1:%[1]s; else exec nc 127.0.0.1 %[1]s; fi", port,
+	)}
+}
@@ -0,0 +1,118 @@
+package testcontainers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// relayClient is a Docker client whose exec instances relay their input to an echo server,
+// as the command relaying the connections to the port of a container does
+type relayClient struct {
+	client.APIClient
+	cmds [][]string
+}
+
+func (c *relayClient) ContainerExecCreate(_ context.Context, _ string, config types.ExecConfig) (types.IDResponse, error) {
+	c.cmds = append(c.cmds, config.Cmd)
+	return types.IDResponse{ID: "exec"}, nil
+}
+
+func (c *relayClient) ContainerExecAttach(_ context.Context, _ string, _ types.ExecStartCheck) (types.HijackedResponse, error) {
+	local, remote := net.Pipe()
+
+	go func() {
+		defer remote.Close()
+		// the output of an exec instance without TTY is multiplexed
+		_, _ = io.Copy(stdcopy.NewStdWriter(remote, stdcopy.Stdout), remote)
+	}()
+
+	return types.HijackedResponse{Conn: local, Reader: bufio.NewReader(local)}, nil
+}
+
+func Test_PortForward(t *testing.T) {
+	cli := &relayClient{}
+	c := &DockerContainer{
+		ID:       "0123456789abcdef0123456789abcdef",
+		provider: &DockerProvider{client: cli},
+		logger:   TestLogger(t),
+	}
+
+	t.Run("relayed data", func(t *testing.T) {
+		addr, stop, err := c.PortForward(context.Background(), "6379/tcp")
+		require.NoError(t, err)
+		defer stop()
+
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("PING\r\n"))
+		require.NoError(t, err)
+
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "PING\r\n", line)
+
+		require.Len(t, cli.cmds, 1)
+		assert.Contains(t, cli.cmds[0][2], "TCP:127.0.0.1:6379")
+	})
+
+	t.Run("stopped forward", func(t *testing.T) {
+		addr, stop, err := c.PortForward(context.Background(), "6379/tcp")
+		require.NoError(t, err)
+
+		stop()
+		stop()
+
+		_, err = net.Dial("tcp", addr)
+		assert.Error(t, err)
+	})
+
+	t.Run("UDP port", func(t *testing.T) {
+		_, _, err := c.PortForward(context.Background(), "53/udp")
+		assert.Error(t, err)
+	})
+}
+
+func TestContainerPortForward(t *testing.T) {
+	ctx := context.Background()
+
+	nginxC, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType: providerType,
+		ContainerRequest: ContainerRequest{
+			Image:      nginxAlpineImage,
+			WaitingFor: wait.ForLog("start worker processes"),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, nginxC)
+
+	// the port is not exposed, so that it can only be reached through the forward
+	addr, stop, err := nginxC.PortForward(ctx, nat.Port(nginxDefaultPort))
+	require.NoError(t, err)
+	defer stop()
+
+	resp, err := http.Get("http://" + addr)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, bytes.Contains(body, []byte("Welcome to nginx")))
+}
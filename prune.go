@@ -0,0 +1,121 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// PruneReport represents the number of resources removed by a prune
+type PruneReport struct {
+	Containers int
+	Networks   int
+	Volumes    int
+}
+
+// PruneSession removes the containers, networks and volumes labelled with the given session ID,
+// e.g. the resources left behind by a crashed test run whose reaper did not run.
+// The reaper container of the session and the shared reaper_default network are kept
+func (p *DockerProvider) PruneSession(ctx context.Context, sessionID string) (PruneReport, error) {
+	return p.prune(ctx, sessionLabels(sessionID))
+}
+
+// PruneAll removes the containers, networks and volumes created by Testcontainers, whatever their session,
+// so it also removes the resources of the test runs in progress. The reaper containers, the shared reaper_default
+// network and the containers created with Reuse, which are not labelled as such, are kept
+func (p *DockerProvider) PruneAll(ctx context.Context) (PruneReport, error) {
+	return p.prune(ctx, map[string]string{TestcontainerLabel: "true"})
+}
+
+// prune removes the resources carrying all the given labels, except the reapers and their network.
+// A resource which cannot be removed, e.g. a network still in use, does not stop the prune:
+// the errors are returned together once all the resources are processed
+func (p *DockerProvider) prune(ctx context.Context, labels map[string]string) (PruneReport, error) {
+	report := PruneReport{}
+	filter := labelFilters(labels)
+	var errs []error
+
+	// the containers are removed first, as the networks and volumes they use cannot be removed
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filter})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, c := range containers {
+		// the reapers carry the labels of their session, and must outlive the containers they clean up
+		if c.Labels[TestcontainerLabelIsReaper] == "true" {
+			continue
+		}
+		err := p.client.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{RemoveVolumes: true, Force: true})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.Containers++
+	}
+
+	networks, err := p.client.NetworkList(ctx, types.NetworkListOptions{Filters: filter})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, n := range networks {
+		// the reaper network is shared by the sessions when the bridge network is not available
+		if n.Name == ReaperDefault {
+			continue
+		}
+		if err := p.client.NetworkRemove(ctx, n.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.Networks++
+	}
+
+	volumes, err := p.client.VolumeList(ctx, filter)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, v := range volumes.Volumes {
+		if err := p.client.VolumeRemove(ctx, v.Name, true); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.Volumes++
+	}
+
+	if len(errs) > 0 {
+		return report, pruneErrors(errs)
+	}
+	return report, nil
+}
+
+// pruneErrors gathers the errors of a prune, as errors.Join is not available in Go 1.18
+type pruneErrors []error
+
+func (e pruneErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports whether any of the errors matches the target
+func (e pruneErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors matching the target
+func (e pruneErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package testcontainers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pruneRecorderClient lists one resource of each kind and records the filters and the removals
+type pruneRecorderClient struct {
+	client.APIClient
+	filters []filters.Args
+	removed []string
+}
+
+func (c *pruneRecorderClient) ContainerList(_ context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	c.filters = append(c.filters, options.Filters)
+	return []types.Container{{ID: "container"}}, nil
+}
+
+func (c *pruneRecorderClient) ContainerRemove(_ context.Context, id string, _ types.ContainerRemoveOptions) error {
+	c.removed = append(c.removed, id)
+	return nil
+}
+
+func (c *pruneRecorderClient) NetworkList(_ context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	c.filters = append(c.filters, options.Filters)
+	return []types.NetworkResource{{ID: "network"}}, nil
+}
+
+func (c *pruneRecorderClient) NetworkRemove(_ context.Context, id string) error {
+	c.removed = append(c.removed, id)
+	return nil
+}
+
+func (c *pruneRecorderClient) VolumeList(_ context.Context, filter filters.Args) (volume.ListResponse, error) {
+	c.filters = append(c.filters, filter)
+	return volume.ListResponse{Volumes: []*volume.Volume{{Name: "volume"}}}, nil
+}
+
+func (c *pruneRecorderClient) VolumeRemove(_ context.Context, id string, _ bool) error {
+	c.removed = append(c.removed, id)
+	return nil
+}
+
+func Test_PruneSessionFiltersOnTheSessionLabels(t *testing.T) {
+	recorder := &pruneRecorderClient{}
+	provider := &DockerProvider{client: recorder}
+
+	report, err := provider.PruneSession(context.Background(), "my-session")
+	require.NoError(t, err)
+
+	assert.Equal(t, PruneReport{Containers: 1, Networks: 1, Volumes: 1}, report)
+	assert.Equal(t, []string{"container", "network", "volume"}, recorder.removed)
+
+	require.Len(t, recorder.filters, 3)
+	for _, f := range recorder.filters {
+		assert.True(t, f.ExactMatch("label", TestcontainerLabel+"=true"))
+		assert.True(t, f.ExactMatch("label", TestcontainerLabelSessionID+"=my-session"))
+	}
+}
+
+var errNetworkBusy = errors.New("network busy has active endpoints")
+
+// reaperPruneClient lists a reaper and its network along with the resources of a session,
+// and fails to remove the first container and the first network
+type reaperPruneClient struct {
+	pruneRecorderClient
+}
+
+func (c *reaperPruneClient) ContainerList(_ context.Context, _ types.ContainerListOptions) ([]types.Container, error) {
+	return []types.Container{
+		{ID: "in-use"},
+		{ID: "reaper", Labels: map[string]string{TestcontainerLabel: "true", TestcontainerLabelIsReaper: "true"}},
+		{ID: "container"},
+	}, nil
+}
+
+func (c *reaperPruneClient) ContainerRemove(ctx context.Context, id string, options types.ContainerRemoveOptions) error {
+	if id == "in-use" {
+		return errors.New("container in-use cannot be removed")
+	}
+	return c.pruneRecorderClient.ContainerRemove(ctx, id, options)
+}
+
+func (c *reaperPruneClient) NetworkList(_ context.Context, _ types.NetworkListOptions) ([]types.NetworkResource, error) {
+	return []types.NetworkResource{{ID: "busy", Name: "busy"}, {ID: "reaper-network", Name: ReaperDefault}, {ID: "network", Name: "network"}}, nil
+}
+
+func (c *reaperPruneClient) NetworkRemove(ctx context.Context, id string) error {
+	if id == "busy" {
+		return errNetworkBusy
+	}
+	return c.pruneRecorderClient.NetworkRemove(ctx, id)
+}
+
+func Test_PruneKeepsTheReapers(t *testing.T) {
+	recorder := &reaperPruneClient{}
+	provider := &DockerProvider{client: recorder}
+
+	report, err := provider.PruneAll(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "container in-use cannot be removed")
+	assert.Contains(t, err.Error(), "network busy has active endpoints")
+	assert.ErrorIs(t, err, errNetworkBusy, "each error should be matched, not only the first one")
+
+	// the failed removals do not stop the prune, and the reaper and its network are kept
+	assert.Equal(t, PruneReport{Containers: 1, Networks: 1, Volumes: 1}, report)
+	assert.Equal(t, []string{"container", "network", "volume"}, recorder.removed)
+}
+
+func TestPruneSession(t *testing.T) {
+	ctx := context.Background()
+	// the resources are labelled with another session, so that the ones of the running tests are kept
+	prunedSessionID := uuid.New().String()
+
+	req := ContainerRequest{
+		Image:      "docker.io/alpine:latest",
+		Cmd:        []string{"sleep", "60"},
+		SkipReaper: true,
+	}
+	require.NoError(t, req.WithSessionLabels(prunedSessionID))
+
+	_, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err)
+
+	_, err = GenericNetwork(ctx, GenericNetworkRequest{
+		ProviderType: providerType,
+		NetworkRequest: NetworkRequest{
+			Name:       "test-network-" + prunedSessionID,
+			Labels:     sessionLabels(prunedSessionID),
+			SkipReaper: true,
+		},
+	})
+	require.NoError(t, err)
+
+	provider, err := NewDockerProvider(WithLogger(TestLogger(t)))
+	require.NoError(t, err)
+
+	report, err := provider.PruneSession(ctx, prunedSessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Containers)
+	assert.Equal(t, 1, report.Networks)
+
+	report, err = provider.PruneSession(ctx, prunedSessionID)
+	require.NoError(t, err)
+	assert.Equal(t, PruneReport{}, report, "nothing should be left to prune")
+}
@@ -3,6 +3,9 @@ package testcontainers
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
@@ -11,6 +14,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/go-connections/nat"
 
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -20,16 +26,32 @@ const (
 	TestcontainerLabel          = "org.testcontainers.golang"
 	TestcontainerLabelSessionID = TestcontainerLabel + ".sessionId"
 	TestcontainerLabelIsReaper  = TestcontainerLabel + ".reaper"
+	TestcontainerLabelHash      = TestcontainerLabel + ".hash"
 
 	ReaperDefaultImage = "docker.io/testcontainers/ryuk:0.3.4"
+
+	// defaultDockerSocketPath is the default location of the Docker socket, both in the host and in the reaper
+	defaultDockerSocketPath = "/var/run/docker.sock"
+
+	// defaultReaperConnectionTimeout is the time allowed to connect and handshake with Ryuk
+	defaultReaperConnectionTimeout = 10 * time.Second
+	// defaultReaperRetries is the number of attempts to register the labels with Ryuk
+	defaultReaperRetries = 3
+	// defaultReaperRetryInterval is the initial delay between two registration attempts, doubled after each attempt
+	defaultReaperRetryInterval = 250 * time.Millisecond
+	// defaultReaperStartupTimeout is the time allowed to start Ryuk and resolve its endpoint
+	defaultReaperStartupTimeout = 60 * time.Second
 )
 
 type reaperContextKey string
 
 var (
 	dockerHostContextKey = reaperContextKey("docker_host")
-	reaper               *Reaper // We would like to create reaper only once
+	reapers              = map[string]*Reaper{} // We would like to create reaper only once per session
 	mutex                sync.Mutex
+
+	// ErrReaperUnreachable is returned when Ryuk cannot be reached or does not acknowledge a request
+	ErrReaperUnreachable = errors.New("reaper is unreachable")
 )
 
 // ReaperProvider represents a provider for the reaper to run itself with
@@ -49,27 +71,75 @@ func NewReaper(ctx context.Context, sessionID string, provider ReaperProvider, r
 func newReaper(ctx context.Context, sessionID string, provider ReaperProvider, opts ...ContainerOption) (*Reaper, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	// If reaper already exists re-use it
-	if reaper != nil {
-		return reaper, nil
+	// If reaper already exists for the session re-use it
+	if r, ok := reapers[sessionID]; ok {
+		return r, nil
 	}
 
-	dockerHost := extractDockerHost(ctx)
+	tcConfig := provider.Config()
 
-	// Otherwise create a new one
-	reaper = &Reaper{
-		Provider:  provider,
-		SessionID: sessionID,
+	// Ryuk cannot run in some environments: return a no-op reaper that keeps labelling resources
+	if tcConfig.RyukDisabled {
+		r := &Reaper{
+			Provider:  provider,
+			SessionID: sessionID,
+			disabled:  true,
+		}
+		reapers[sessionID] = r
+		return r, nil
 	}
 
-	listeningPort := nat.Port("8080/tcp")
+	dockerHost := extractDockerHost(ctx)
+	dockerSocketTarget := extractDockerSocketTarget()
 
-	reaperOpts := containerOptions{}
+	reaperOpts := containerOptions{
+		ConnectionTimeout: defaultReaperConnectionTimeout,
+		Retries:           defaultReaperRetries,
+		RetryInterval:     defaultReaperRetryInterval,
+		StartupTimeout:    defaultReaperStartupTimeout,
+	}
+
+	if timeoutEnv := os.Getenv("TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT"); timeoutEnv != "" {
+		timeout, err := time.ParseDuration(timeoutEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT", err)
+		}
+		reaperOpts.ConnectionTimeout = timeout
+	}
 
 	for _, opt := range opts {
 		opt(&reaperOpts)
 	}
 
+	if err := validateRegistryCredentials(reaperImage(reaperOpts.ImageName), reaperOpts.RegistryCredentials); err != nil {
+		return nil, err
+	}
+
+	// the port Ryuk listens on is exposed by the reaper container, so it cannot be changed
+	if _, ok := reaperOpts.Env["RYUK_PORT"]; ok {
+		return nil, errors.New("reaper environment variable RYUK_PORT cannot be overridden")
+	}
+
+	for k := range reaperOpts.Labels {
+		if strings.HasPrefix(k, TestcontainerLabel) {
+			return nil, fmt.Errorf("reaper label %s is reserved by testcontainers", k)
+		}
+	}
+
+	// Otherwise create a new one, which is only shared once it is up and running
+	// so that a failed attempt does not leave a poisoned singleton behind
+	r := &Reaper{
+		Provider:          provider,
+		SessionID:         sessionID,
+		connectionTimeout: reaperOpts.ConnectionTimeout,
+		retries:           reaperOpts.Retries,
+		retryInterval:     reaperOpts.RetryInterval,
+		extraLabels:       reaperOpts.Labels,
+		logger:            reaperOpts.Logger,
+	}
+
+	listeningPort := nat.Port("8080/tcp")
+
 	req := ContainerRequest{
 		Image:        reaperImage(reaperOpts.ImageName),
 		ExposedPorts: []string{string(listeningPort)},
@@ -79,7 +149,7 @@ func newReaper(ctx context.Context, sessionID string, provider ReaperProvider, o
 		},
 		SkipReaper:    true,
 		RegistryCred:  reaperOpts.RegistryCredentials,
-		Mounts:        Mounts(BindMount(dockerHost, "/var/run/docker.sock")),
+		Mounts:        Mounts(BindMount(dockerHost, ContainerMountTarget(dockerSocketTarget))),
 		AutoRemove:    true,
 		WaitingFor:    wait.ForListeningPort(listeningPort),
 		ReaperOptions: opts,
@@ -88,31 +158,71 @@ func newReaper(ctx context.Context, sessionID string, provider ReaperProvider, o
 	// keep backwards compatibility
 	req.ReaperImage = req.Image
 
+	// Ryuk looks for the Docker socket at its default location, unless told otherwise
+	if dockerSocketTarget != defaultDockerSocketPath {
+		req.Env = map[string]string{
+			"DOCKER_HOST": "unix://" + dockerSocketTarget,
+		}
+	}
+
+	// tune Ryuk with the user-provided environment
+	if len(reaperOpts.Env) > 0 {
+		if req.Env == nil {
+			req.Env = map[string]string{}
+		}
+		for k, v := range reaperOpts.Env {
+			req.Env[k] = v
+		}
+	}
+
 	// include reaper-specific labels to the reaper container
-	for k, v := range reaper.Labels() {
+	for k, v := range r.Labels() {
+		req.Labels[k] = v
+	}
+	for k, v := range r.extraLabels {
 		req.Labels[k] = v
 	}
 
-	tcConfig := provider.Config()
 	req.Privileged = tcConfig.RyukPrivileged
+	if reaperOpts.Privileged != nil {
+		req.Privileged = *reaperOpts.Privileged
+	}
 
 	// Attach reaper container to a requested network if it is specified
 	if p, ok := provider.(*DockerProvider); ok {
 		req.Networks = append(req.Networks, p.DefaultNetwork)
 	}
 
-	c, err := provider.RunContainer(ctx, req)
+	// Attach reaper container to the user-specified networks, which must exist
+	if len(reaperOpts.Networks) > 0 {
+		if np, ok := provider.(NetworkProvider); ok {
+			for _, n := range reaperOpts.Networks {
+				if _, err := np.GetNetwork(ctx, NetworkRequest{Name: n}); err != nil {
+					return nil, fmt.Errorf("%w: reaper network %s not found", err, n)
+				}
+			}
+		}
+		req.Networks = append(req.Networks, reaperOpts.Networks...)
+	}
+
+	// bound the time to bring Ryuk up, so that a hung pull does not block the whole test run
+	startupCtx, cancel := context.WithTimeout(ctx, reaperOpts.StartupTimeout)
+	defer cancel()
+
+	c, err := provider.RunContainer(startupCtx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint, err := c.PortEndpoint(ctx, "8080", "")
+	endpoint, err := c.PortEndpoint(startupCtx, "8080", "")
 	if err != nil {
 		return nil, err
 	}
-	reaper.Endpoint = endpoint
+	r.Endpoint = endpoint
+	r.container = c
 
-	return reaper, nil
+	reapers[sessionID] = r
+	return r, nil
 }
 
 // Reaper is used to start a sidecar container that cleans up resources
@@ -120,70 +230,280 @@ type Reaper struct {
 	Provider  ReaperProvider
 	SessionID string
 	Endpoint  string
+
+	container         Container
+	connectionTimeout time.Duration
+	retries           int
+	retryInterval     time.Duration
+	extraLabels       map[string]string
+	logger            Logging
+	disabled          bool
+
+	connectionsMx sync.Mutex
+	connections   []*reaperConnection // the connections which are still open
+}
+
+// reaperConnection tracks a connection opened by Connect
+type reaperConnection struct {
+	terminationSignal chan bool
+	done              chan struct{} // closed once the connection to Ryuk is closed
+}
+
+// Container returns the Ryuk container, e.g. to read its logs when debugging the cleanup of resources.
+// It returns nil if the reaper is disabled
+func (r *Reaper) Container() Container {
+	return r.container
 }
 
 // Connect runs a goroutine which can be terminated by sending true into the returned channel
+// Both the dial and the handshake with Ryuk are bounded by the connection timeout, 10 seconds by default
+// If the reaper is disabled, there is nothing to connect to: the returned channel is never read, but it is buffered
+// so that the termination signal, which is sent once, does not block
 func (r *Reaper) Connect() (chan bool, error) {
-	conn, err := net.DialTimeout("tcp", r.Endpoint, 10*time.Second)
+	if r.disabled {
+		return make(chan bool, 1), nil
+	}
+
+	timeout := r.connectionTimeout
+	if timeout == 0 {
+		timeout = defaultReaperConnectionTimeout
+	}
+
+	conn, err := net.DialTimeout("tcp", r.Endpoint, timeout)
 	if err != nil {
 		return nil, fmt.Errorf("%w: Connecting to Ryuk on %s failed", err, r.Endpoint)
 	}
 
 	terminationSignal := make(chan bool)
+	rc := &reaperConnection{
+		terminationSignal: terminationSignal,
+		done:              make(chan struct{}),
+	}
+
+	r.connectionsMx.Lock()
+	r.connections = append(r.connections, rc)
+	r.connectionsMx.Unlock()
+
 	go func(conn net.Conn) {
 		sock := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+		defer r.removeConnection(rc)
+		defer close(rc.done)
 		defer conn.Close()
 
-		labelFilters := []string{}
-		for l, v := range r.Labels() {
-			labelFilters = append(labelFilters, fmt.Sprintf("label=%s=%s", l, v))
+		// a slow handshake must not hang forever
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+
+		for _, filter := range r.labelFilters() {
+			r.register(sock, filter)
 		}
 
-		retryLimit := 3
-		for retryLimit > 0 {
-			retryLimit--
+		// the connection must stay open until termination, so the deadline is removed
+		_ = conn.SetDeadline(time.Time{})
 
-			if _, err := sock.WriteString(strings.Join(labelFilters, "&")); err != nil {
-				continue
-			}
+		<-terminationSignal
+	}(conn)
+	return terminationSignal, nil
+}
 
-			if _, err := sock.WriteString("\n"); err != nil {
-				continue
-			}
+// Shutdown terminates all the connections opened by Connect, waiting for them to be closed
+// or for the context to be done
+func (r *Reaper) Shutdown(ctx context.Context) error {
+	// the lock is not held while talking to Ryuk, so that Connect is not blocked meanwhile
+	r.connectionsMx.Lock()
+	connections := make([]*reaperConnection, len(r.connections))
+	copy(connections, r.connections)
+	r.connectionsMx.Unlock()
+
+	for _, c := range connections {
+		select {
+		case c.terminationSignal <- true:
+		case <-c.done:
+			// already terminated, e.g. by the container it was created for
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 
-			if err := sock.Flush(); err != nil {
-				continue
-			}
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
-			resp, err := sock.ReadString('\n')
-			if err != nil {
-				continue
-			}
+	return nil
+}
 
-			if resp == "ACK\n" {
-				break
-			}
+// removeConnection forgets a connection once it is closed
+func (r *Reaper) removeConnection(rc *reaperConnection) {
+	r.connectionsMx.Lock()
+	defer r.connectionsMx.Unlock()
+
+	for i, c := range r.connections {
+		if c == rc {
+			r.connections = append(r.connections[:i], r.connections[i+1:]...)
+			return
 		}
+	}
+}
 
-		<-terminationSignal
-	}(conn)
-	return terminationSignal, nil
+// register sends a label filter to Ryuk, retrying with an exponential backoff until it is acknowledged
+func (r *Reaper) register(sock *bufio.ReadWriter, filter string) {
+	logger := r.logger
+	if logger == nil {
+		logger = noopLogger{}
+	}
+
+	retryLimit := r.retries
+	if retryLimit <= 0 {
+		retryLimit = defaultReaperRetries
+	}
+
+	retryInterval := r.retryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultReaperRetryInterval
+	}
+
+	for attempt := 0; attempt < retryLimit; attempt++ {
+		if attempt > 0 {
+			// Ryuk might be listening but not fully initialized yet, back off before retrying
+			time.Sleep(retryInterval)
+			retryInterval *= 2
+		}
+
+		if _, err := sock.WriteString(filter); err != nil {
+			logger.Printf("DEBUG: (%d/%d) writing the label filter to Ryuk on %s failed: %s", attempt+1, retryLimit, r.Endpoint, err)
+			continue
+		}
+
+		if _, err := sock.WriteString("\n"); err != nil {
+			logger.Printf("DEBUG: (%d/%d) writing the label filter to Ryuk on %s failed: %s", attempt+1, retryLimit, r.Endpoint, err)
+			continue
+		}
+
+		if err := sock.Flush(); err != nil {
+			logger.Printf("DEBUG: (%d/%d) flushing the label filter to Ryuk on %s failed: %s", attempt+1, retryLimit, r.Endpoint, err)
+			continue
+		}
+
+		resp, err := sock.ReadString('\n')
+		if err != nil {
+			logger.Printf("DEBUG: (%d/%d) reading the acknowledgement from Ryuk on %s failed: %s", attempt+1, retryLimit, r.Endpoint, err)
+			continue
+		}
+
+		if resp == "ACK\n" {
+			return
+		}
+
+		logger.Printf("DEBUG: (%d/%d) unexpected response from Ryuk on %s: %q", attempt+1, retryLimit, r.Endpoint, resp)
+	}
+
+	logger.Printf("WARNING: Ryuk on %s did not acknowledge the label filter %q after %d attempts, resources might not be cleaned up", r.Endpoint, filter, retryLimit)
+}
+
+// labelFilters returns the filters sent to Ryuk. Ryuk removes the resources matching all the labels
+// of any of the filters, so the extra labels are sent apart from the session ones
+func (r *Reaper) labelFilters() []string {
+	filters := []string{labelFilter(r.Labels())}
+	if len(r.extraLabels) > 0 {
+		filters = append(filters, labelFilter(r.extraLabels))
+	}
+
+	return filters
+}
+
+func labelFilter(labels map[string]string) string {
+	labelFilters := []string{}
+	for l, v := range labels {
+		labelFilters = append(labelFilters, fmt.Sprintf("label=%s=%s", l, v))
+	}
+
+	return strings.Join(labelFilters, "&")
+}
+
+// Ping checks that Ryuk is still alive, using a short-lived connection that does not
+// interfere with the one created by Connect. It sends the session label filter, which Ryuk
+// already knows from Connect, and waits for Ryuk to acknowledge it before the context deadline,
+// or the connection timeout if there is none. An empty filter must not be sent, as Ryuk would
+// then remove every resource of the Docker daemon. It returns nil if the reaper is disabled.
+func (r *Reaper) Ping(ctx context.Context) error {
+	if r.disabled {
+		return nil
+	}
+
+	timeout := r.connectionTimeout
+	if timeout == 0 {
+		timeout = defaultReaperConnectionTimeout
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", r.Endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReaperUnreachable, err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("%w: %v", ErrReaperUnreachable, err)
+	}
+
+	sock := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if _, err := sock.WriteString(labelFilter(r.Labels()) + "\n"); err != nil {
+		return fmt.Errorf("%w: %v", ErrReaperUnreachable, err)
+	}
+
+	if err := sock.Flush(); err != nil {
+		return fmt.Errorf("%w: %v", ErrReaperUnreachable, err)
+	}
+
+	resp, err := sock.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReaperUnreachable, err)
+	}
+
+	if resp != "ACK\n" {
+		return fmt.Errorf("%w: unexpected response %q", ErrReaperUnreachable, resp)
+	}
+
+	return nil
 }
 
 // Labels returns the container labels to use so that this Reaper cleans them up
 func (r *Reaper) Labels() map[string]string {
+	return sessionLabels(r.SessionID)
+}
+
+// sessionLabels returns the labels identifying the resources of a session
+func sessionLabels(sessionID string) map[string]string {
 	return map[string]string{
 		TestcontainerLabel:          "true",
-		TestcontainerLabelSessionID: r.SessionID,
+		TestcontainerLabelSessionID: sessionID,
 	}
 }
 
+// labelFilters returns the filters matching the Docker resources carrying all the given labels
+func labelFilters(labels map[string]string) filters.Args {
+	filter := filters.NewArgs()
+	for k, v := range labels {
+		filter.Add("label", k+"="+v)
+	}
+
+	return filter
+}
+
 func extractDockerHost(ctx context.Context) (dockerHostPath string) {
 	if dockerHostPath = os.Getenv("TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE"); dockerHostPath != "" {
 		return dockerHostPath
 	}
 
-	dockerHostPath = "/var/run/docker.sock"
+	dockerHostPath = defaultDockerSocketPath
 
 	var hostRawURL string
 	if h, ok := ctx.Value(dockerHostContextKey).(string); !ok || h == "" {
@@ -206,6 +526,50 @@ func extractDockerHost(ctx context.Context) (dockerHostPath string) {
 	}
 }
 
+// validateRegistryCredentials checks that the credentials used to pull the reaper image from a private registry
+// are a base64 encoded auth config, as expected by the Docker API, instead of failing later with an anonymous pull
+func validateRegistryCredentials(imageName string, credentials string) error {
+	if credentials == "" {
+		return nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		// the image name is checked by the Docker daemon when pulling it
+		return nil
+	}
+
+	registry := reference.Domain(named)
+	if registry == "docker.io" {
+		return nil
+	}
+
+	authJSON, err := base64.URLEncoding.DecodeString(credentials)
+	if err != nil {
+		authJSON, err = base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return fmt.Errorf("%w: registry credentials for %s are not base64 encoded", err, registry)
+		}
+	}
+
+	var authConfig types.AuthConfig
+	if err := json.Unmarshal(authJSON, &authConfig); err != nil {
+		return fmt.Errorf("%w: registry credentials for %s are not a valid auth config", err, registry)
+	}
+
+	return nil
+}
+
+// extractDockerSocketTarget returns the path where the Docker socket is mounted in the reaper container,
+// which can be overridden with the TESTCONTAINERS_RYUK_SOCKET_TARGET environment variable
+func extractDockerSocketTarget() string {
+	if target := os.Getenv("TESTCONTAINERS_RYUK_SOCKET_TARGET"); target != "" {
+		return target
+	}
+
+	return defaultDockerSocketPath
+}
+
 func reaperImage(reaperImageName string) string {
 	if reaperImageName == "" {
 		return ReaperDefaultImage
@@ -1,12 +1,22 @@
 package testcontainers
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
@@ -29,6 +39,137 @@ func (m *mockReaperProvider) Config() TestContainersConfig {
 	return m.config
 }
 
+// mockRunningReaperProvider starts a fake reaper container exposing the given endpoint
+type mockRunningReaperProvider struct {
+	mockReaperProvider
+	endpoint string
+	started  int // number of reaper containers started
+}
+
+func (m *mockRunningReaperProvider) RunContainer(ctx context.Context, req ContainerRequest) (Container, error) {
+	m.req = req
+	m.started++
+
+	return &mockReaperContainer{endpoint: m.endpoint}, nil
+}
+
+// mockNetworkReaperProvider knows about a set of existing networks
+type mockNetworkReaperProvider struct {
+	mockReaperProvider
+	networks []string
+}
+
+func (m *mockNetworkReaperProvider) CreateNetwork(ctx context.Context, req NetworkRequest) (Network, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockNetworkReaperProvider) GetNetwork(ctx context.Context, req NetworkRequest) (types.NetworkResource, error) {
+	for _, n := range m.networks {
+		if n == req.Name {
+			return types.NetworkResource{Name: n}, nil
+		}
+	}
+
+	return types.NetworkResource{}, errors.New("network not found")
+}
+
+// mockHangingReaperProvider never starts the reaper container, until the context is done
+type mockHangingReaperProvider struct {
+	mockReaperProvider
+}
+
+func (m *mockHangingReaperProvider) RunContainer(ctx context.Context, req ContainerRequest) (Container, error) {
+	m.req = req
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// mockReaperContainer only implements the methods used by newReaper
+type mockReaperContainer struct {
+	Container
+	endpoint string
+}
+
+func (c *mockReaperContainer) PortEndpoint(ctx context.Context, port nat.Port, proto string) (string, error) {
+	return c.endpoint, nil
+}
+
+// fakeRyuk emulates the Ryuk protocol: it acknowledges every line of label filters it receives,
+// and, as Ryuk, does not answer a line which is not a query string
+type fakeRyuk struct {
+	listener net.Listener
+	filters  chan string
+	closed   chan struct{} // receives a value every time a connection is closed by the client
+	// rejections is the number of label filters answered with something else than an ACK
+	rejections int
+}
+
+func newFakeRyuk(t *testing.T) *fakeRyuk {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	ryuk := &fakeRyuk{
+		listener: listener,
+		filters:  make(chan string, 10),
+		closed:   make(chan struct{}, 10),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go ryuk.handle(conn)
+		}
+	}()
+
+	return ryuk
+}
+
+func (f *fakeRyuk) handle(conn net.Conn) {
+	defer conn.Close()
+
+	sock := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := sock.ReadString('\n')
+		if err != nil {
+			f.closed <- struct{}{}
+			return
+		}
+
+		f.filters <- line
+
+		if _, err := url.ParseQuery(strings.TrimSuffix(line, "\n")); err != nil {
+			continue
+		}
+
+		resp := "ACK\n"
+		if f.rejections > 0 {
+			f.rejections--
+			resp = "NOT READY\n"
+		}
+
+		if _, err := sock.WriteString(resp); err != nil {
+			return
+		}
+		if err := sock.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRyuk) Endpoint() string {
+	return f.listener.Addr().String()
+}
+
 // createContainerRequest creates the expected request and allows for customization
 func createContainerRequest(customize func(ContainerRequest) ContainerRequest) ContainerRequest {
 	req := ContainerRequest{
@@ -57,7 +198,7 @@ func createContainerRequest(customize func(ContainerRequest) ContainerRequest) C
 }
 
 func Test_NewReaper(t *testing.T) {
-	defer func() { reaper = nil }()
+	defer func() { reapers = map[string]*Reaper{} }()
 
 	type cases struct {
 		name   string
@@ -82,6 +223,25 @@ func Test_NewReaper(t *testing.T) {
 				RyukPrivileged: true,
 			},
 		},
+		{
+			name: "privileged option wins over config",
+			req: createContainerRequest(func(req ContainerRequest) ContainerRequest {
+				req.ReaperOptions = append(req.ReaperOptions, WithReaperPrivileged(false))
+				return req
+			}),
+			config: TestContainersConfig{
+				RyukPrivileged: true,
+			},
+		},
+		{
+			name: "privileged option without config",
+			req: createContainerRequest(func(req ContainerRequest) ContainerRequest {
+				req.Privileged = true
+				req.ReaperOptions = append(req.ReaperOptions, WithReaperPrivileged(true))
+				return req
+			}),
+			config: TestContainersConfig{},
+		},
 		{
 			name: "docker-host in context",
 			req: createContainerRequest(func(req ContainerRequest) ContainerRequest {
@@ -106,7 +266,7 @@ func Test_NewReaper(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			// make sure we re-initialize the singleton
-			reaper = nil
+			reapers = map[string]*Reaper{}
 			provider := &mockReaperProvider{
 				config: test.config,
 			}
@@ -125,7 +285,7 @@ func Test_NewReaper(t *testing.T) {
 }
 
 func Test_ExtractDockerHost(t *testing.T) {
-	defer func() { reaper = nil }()
+	defer func() { reapers = map[string]*Reaper{} }()
 
 	t.Run("Docker Host as environment variable", func(t *testing.T) {
 		t.Setenv("TESTCONTAINERS_DOCKER_SOCKET_OVERRIDE", "/path/to/docker.sock")
@@ -165,8 +325,53 @@ func Test_ExtractDockerHost(t *testing.T) {
 	})
 }
 
+func Test_ExtractDockerSocketTarget(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	t.Run("Default socket target", func(t *testing.T) {
+		assert.Equal(t, "/var/run/docker.sock", extractDockerSocketTarget())
+	})
+
+	t.Run("Socket target as environment variable", func(t *testing.T) {
+		t.Setenv("TESTCONTAINERS_RYUK_SOCKET_TARGET", "/run/docker.sock")
+
+		assert.Equal(t, "/run/docker.sock", extractDockerSocketTarget())
+	})
+
+	t.Run("Socket target is used by the reaper container", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		t.Setenv("TESTCONTAINERS_RYUK_SOCKET_TARGET", "/run/docker.sock")
+
+		provider := &mockReaperProvider{}
+
+		_, err := newReaper(context.Background(), "sessionId", provider)
+		assert.EqualError(t, err, "expected")
+
+		assert.Equal(t, Mounts(BindMount("/var/run/docker.sock", "/run/docker.sock")), provider.req.Mounts)
+		assert.Equal(t, "unix:///run/docker.sock", provider.req.Env["DOCKER_HOST"])
+	})
+
+	t.Run("Podman socket is mounted in the reaper container", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("DOCKER_HOST", "unix:///run/user/1000/podman/podman.sock")
+
+		// the provider passes the Docker host detected by NewDockerClient to the reaper
+		_, host, _, err := NewDockerClient()
+		require.NoError(t, err)
+		ctx := context.WithValue(context.Background(), dockerHostContextKey, host)
+
+		provider := &mockReaperProvider{}
+
+		_, err = newReaper(ctx, "sessionId", provider)
+		assert.EqualError(t, err, "expected")
+
+		assert.Equal(t, Mounts(BindMount("/run/user/1000/podman/podman.sock", "/var/run/docker.sock")), provider.req.Mounts)
+	})
+}
+
 func Test_ReaperForNetwork(t *testing.T) {
-	defer func() { reaper = nil }()
+	defer func() { reapers = map[string]*Reaper{} }()
 
 	ctx := context.Background()
 
@@ -194,3 +399,519 @@ func Test_ReaperForNetwork(t *testing.T) {
 	assert.Equal(t, "reaperImage", provider.req.Image)
 	assert.Equal(t, "reaperImage", provider.req.ReaperImage)
 }
+
+func Test_ReaperConnectionTimeout(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	t.Run("default", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+		r, err := newReaper(context.Background(), "sessionId", provider)
+		require.NoError(t, err)
+
+		assert.Equal(t, 10*time.Second, r.connectionTimeout)
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		t.Setenv("TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT", "30s")
+		provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+		r, err := newReaper(context.Background(), "sessionId", provider)
+		require.NoError(t, err)
+
+		assert.Equal(t, 30*time.Second, r.connectionTimeout)
+	})
+
+	t.Run("option takes precedence over environment", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		t.Setenv("TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT", "30s")
+		provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+		r, err := newReaper(context.Background(), "sessionId", provider, WithReaperConnectTimeout(time.Minute))
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Minute, r.connectionTimeout)
+	})
+
+	t.Run("invalid environment value", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		t.Setenv("TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT", "not-a-duration")
+		provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+		_, err := newReaper(context.Background(), "sessionId", provider)
+		assert.ErrorContains(t, err, "invalid TESTCONTAINERS_RYUK_CONNECTION_TIMEOUT")
+	})
+}
+
+func Test_ReaperConnect(t *testing.T) {
+	ryuk := newFakeRyuk(t)
+
+	r := &Reaper{
+		SessionID:         "sessionId",
+		Endpoint:          ryuk.Endpoint(),
+		connectionTimeout: time.Second,
+	}
+
+	termSignal, err := r.Connect()
+	require.NoError(t, err)
+	defer func() { termSignal <- true }()
+
+	select {
+	case filter := <-ryuk.filters:
+		assert.Contains(t, filter, "label="+TestcontainerLabelSessionID+"=sessionId")
+	case <-time.After(5 * time.Second):
+		t.Fatal("reaper did not send its label filters")
+	}
+}
+
+func Test_ReaperPing(t *testing.T) {
+	t.Run("reaper is alive", func(t *testing.T) {
+		ryuk := newFakeRyuk(t)
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: ryuk.Endpoint()}
+
+		assert.NoError(t, r.Ping(context.Background()))
+
+		// an empty filter would match every resource of the Docker daemon
+		query, err := url.ParseQuery(strings.TrimSuffix(<-ryuk.filters, "\n"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			TestcontainerLabel + "=true",
+			TestcontainerLabelSessionID + "=sessionId",
+		}, query["label"])
+	})
+
+	t.Run("reaper is disabled", func(t *testing.T) {
+		// there is no endpoint to ping
+		r := &Reaper{SessionID: "sessionId", disabled: true}
+
+		assert.NoError(t, r.Ping(context.Background()))
+	})
+
+	t.Run("reaper is gone", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		endpoint := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: endpoint}
+
+		err = r.Ping(context.Background())
+		assert.ErrorIs(t, err, ErrReaperUnreachable)
+	})
+
+	t.Run("reaper does not acknowledge", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: listener.Addr().String()}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		err = r.Ping(ctx)
+		assert.ErrorIs(t, err, ErrReaperUnreachable)
+	})
+}
+
+func Test_NewReaperDisabled(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	provider := &mockReaperProvider{
+		config: TestContainersConfig{
+			RyukDisabled: true,
+		},
+	}
+
+	r, err := newReaper(context.Background(), "sessionId", provider)
+	require.NoError(t, err)
+
+	// no reaper container must be started
+	assert.Empty(t, provider.req.Image)
+
+	termSignal, err := r.Connect()
+	require.NoError(t, err)
+
+	// the termination signal, sent when the resource is removed, must neither panic nor block
+	assert.NotPanics(t, func() { termSignal <- true })
+
+	assert.Equal(t, "sessionId", r.Labels()[TestcontainerLabelSessionID])
+}
+
+func Test_SessionReaper(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	reaper := &Reaper{SessionID: SessionID(), disabled: true}
+	reapers[SessionID()] = reaper
+
+	provider := &DockerProvider{}
+
+	// the reaper of the session is shared with the containers and networks of the provider
+	r, err := provider.SessionReaper(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, reaper, r)
+}
+
+func Test_ReaperConnectRetries(t *testing.T) {
+	ryuk := newFakeRyuk(t)
+	ryuk.rejections = 2
+
+	r := &Reaper{
+		SessionID:         "sessionId",
+		Endpoint:          ryuk.Endpoint(),
+		connectionTimeout: 5 * time.Second,
+		retries:           3,
+		retryInterval:     10 * time.Millisecond,
+	}
+
+	termSignal, err := r.Connect()
+	require.NoError(t, err)
+	defer func() { termSignal <- true }()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ryuk.filters:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("reaper did not retry, only %d attempts received", i)
+		}
+	}
+}
+
+func Test_ReaperRetryOptions(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+	reapers = map[string]*Reaper{}
+
+	provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+	r, err := newReaper(context.Background(), "sessionId", provider, WithReaperRetries(5), WithReaperRetryInterval(time.Second))
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, r.retries)
+	assert.Equal(t, time.Second, r.retryInterval)
+}
+
+func Test_ReaperLabels(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	t.Run("extra labels are sent as a separate filter", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		ryuk := newFakeRyuk(t)
+		provider := &mockRunningReaperProvider{endpoint: ryuk.Endpoint()}
+
+		r, err := newReaper(context.Background(), "sessionId", provider, WithReaperLabels(map[string]string{"ci.build": "42"}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "42", provider.req.Labels["ci.build"])
+
+		termSignal, err := r.Connect()
+		require.NoError(t, err)
+		defer func() { termSignal <- true }()
+
+		filters := make([]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			select {
+			case filter := <-ryuk.filters:
+				filters = append(filters, filter)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("reaper sent %d filters, expected 2", i)
+			}
+		}
+
+		assert.Contains(t, filters[0], "label="+TestcontainerLabelSessionID+"=sessionId")
+		assert.Equal(t, "label=ci.build=42\n", filters[1])
+	})
+
+	t.Run("reserved labels are rejected", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+		_, err := newReaper(context.Background(), "sessionId", provider, WithReaperLabels(map[string]string{TestcontainerLabelSessionID: "other"}))
+		assert.ErrorContains(t, err, "is reserved")
+	})
+}
+
+func Test_ReaperShutdown(t *testing.T) {
+	t.Run("connections are closed", func(t *testing.T) {
+		ryuk := newFakeRyuk(t)
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: ryuk.Endpoint()}
+
+		_, err := r.Connect()
+		require.NoError(t, err)
+		<-ryuk.filters
+
+		require.NoError(t, r.Shutdown(context.Background()))
+
+		select {
+		case <-ryuk.closed:
+		case <-time.After(time.Second):
+			t.Fatal("connection to the reaper is still open")
+		}
+	})
+
+	t.Run("connection already terminated", func(t *testing.T) {
+		ryuk := newFakeRyuk(t)
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: ryuk.Endpoint()}
+
+		termSignal, err := r.Connect()
+		require.NoError(t, err)
+		<-ryuk.filters
+
+		termSignal <- true
+		<-ryuk.closed
+
+		// the closed connection is forgotten
+		assert.Eventually(t, func() bool {
+			r.connectionsMx.Lock()
+			defer r.connectionsMx.Unlock()
+			return len(r.connections) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, r.Shutdown(context.Background()))
+	})
+
+	t.Run("context is done", func(t *testing.T) {
+		// Ryuk never acknowledges the labels, so the connection is stuck in the handshake
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+
+		r := &Reaper{SessionID: "sessionId", Endpoint: listener.Addr().String()}
+
+		_, err = r.Connect()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		shutdown := make(chan error)
+		go func() {
+			shutdown <- r.Shutdown(ctx)
+		}()
+
+		// the connections can still be opened while the shutdown is waiting for Ryuk
+		connected := make(chan error)
+		go func() {
+			_, err := r.Connect()
+			connected <- err
+		}()
+		select {
+		case err := <-connected:
+			require.NoError(t, err)
+		case <-time.After(250 * time.Millisecond):
+			t.Fatal("Connect is blocked by Shutdown")
+		}
+
+		assert.ErrorIs(t, <-shutdown, context.DeadlineExceeded)
+	})
+}
+
+func Test_ReaperRegistryCredentials(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	validCredentials := base64.URLEncoding.EncodeToString([]byte(`{"username":"user","password":"secret"}`))
+
+	tests := []struct {
+		name        string
+		image       string
+		credentials string
+		expectedErr string
+	}{
+		{
+			name:        "private registry with valid credentials",
+			image:       "registry.example.com/testcontainers/ryuk:0.3.4",
+			credentials: validCredentials,
+			expectedErr: "expected",
+		},
+		{
+			name:        "private registry with credentials not base64 encoded",
+			image:       "registry.example.com/testcontainers/ryuk:0.3.4",
+			credentials: "user:secret",
+			expectedErr: "registry credentials for registry.example.com are not base64 encoded",
+		},
+		{
+			name:        "private registry with credentials not being an auth config",
+			image:       "registry.example.com:5000/testcontainers/ryuk:0.3.4",
+			credentials: base64.URLEncoding.EncodeToString([]byte("user:secret")),
+			expectedErr: "registry credentials for registry.example.com:5000 are not a valid auth config",
+		},
+		{
+			name:        "Docker Hub credentials are not validated",
+			image:       "testcontainers/ryuk:0.3.4",
+			credentials: "user:secret",
+			expectedErr: "expected",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reapers = map[string]*Reaper{}
+			provider := &mockReaperProvider{}
+
+			_, err := newReaper(context.Background(), "sessionId", provider, WithImageName(test.image), WithRegistryCredentials(test.credentials))
+			assert.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func Test_ReaperStartupTimeout(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+	reapers = map[string]*Reaper{}
+
+	hanging := &mockHangingReaperProvider{}
+
+	_, err := newReaper(context.Background(), "sessionId", hanging, WithReaperStartupTimeout(50*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the timed-out reaper must not be reused
+	assert.NotContains(t, reapers, "sessionId")
+
+	provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+	r, err := newReaper(context.Background(), "sessionId", provider)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8080", r.Endpoint)
+	assert.Same(t, r, reapers["sessionId"])
+}
+
+func Test_ReaperNetworks(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	t.Run("networks are attached", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockNetworkReaperProvider{networks: []string{"app-network", "db-network"}}
+
+		_, err := newReaper(context.Background(), "sessionId", provider, WithReaperNetworks("app-network", "db-network"))
+		assert.EqualError(t, err, "expected")
+
+		assert.Equal(t, []string{"app-network", "db-network"}, provider.req.Networks)
+	})
+
+	t.Run("missing network", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockNetworkReaperProvider{networks: []string{"app-network"}}
+
+		_, err := newReaper(context.Background(), "sessionId", provider, WithReaperNetworks("app-network", "db-network"))
+		assert.ErrorContains(t, err, "reaper network db-network not found")
+
+		// the reaper container must not be started
+		assert.Empty(t, provider.req.Image)
+	})
+}
+
+func Test_ReaperPerSession(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+	reapers = map[string]*Reaper{}
+
+	provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+	r1, err := newReaper(context.Background(), "session-1", provider)
+	require.NoError(t, err)
+
+	r2, err := newReaper(context.Background(), "session-2", provider)
+	require.NoError(t, err)
+
+	assert.NotSame(t, r1, r2)
+	assert.Equal(t, "session-1", r1.Labels()[TestcontainerLabelSessionID])
+	assert.Equal(t, "session-2", r2.Labels()[TestcontainerLabelSessionID])
+	assert.Equal(t, 2, provider.started, "a reaper container is expected per session")
+
+	// the same session reuses its reaper
+	r3, err := newReaper(context.Background(), "session-1", provider)
+	require.NoError(t, err)
+
+	assert.Same(t, r1, r3)
+	assert.Equal(t, 2, provider.started)
+}
+
+func Test_ReaperContainer(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+	reapers = map[string]*Reaper{}
+
+	provider := &mockRunningReaperProvider{endpoint: "127.0.0.1:8080"}
+
+	r, err := newReaper(context.Background(), "sessionId", provider)
+	require.NoError(t, err)
+
+	require.NotNil(t, r.Container())
+
+	endpoint, err := r.Container().PortEndpoint(context.Background(), "8080", "")
+	require.NoError(t, err)
+	assert.Equal(t, r.Endpoint, endpoint)
+}
+
+// recordingLogger keeps the logged messages
+type recordingLogger struct {
+	mx       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func (l *recordingLogger) Messages() []string {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return append([]string{}, l.messages...)
+}
+
+func Test_ReaperLogger(t *testing.T) {
+	ryuk := newFakeRyuk(t)
+	ryuk.rejections = 2
+
+	logger := &recordingLogger{}
+
+	r := &Reaper{
+		SessionID:     "sessionId",
+		Endpoint:      ryuk.Endpoint(),
+		retries:       2,
+		retryInterval: 10 * time.Millisecond,
+		logger:        logger,
+	}
+
+	termSignal, err := r.Connect()
+	require.NoError(t, err)
+	defer func() { termSignal <- true }()
+
+	assert.Eventually(t, func() bool {
+		return len(logger.Messages()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	messages := logger.Messages()
+	assert.Contains(t, messages[0], "DEBUG: (1/2) unexpected response from Ryuk")
+	assert.Contains(t, messages[1], "DEBUG: (2/2) unexpected response from Ryuk")
+	assert.Contains(t, messages[2], "WARNING: Ryuk on "+ryuk.Endpoint()+" did not acknowledge the label filter")
+}
+
+func Test_ReaperEnv(t *testing.T) {
+	defer func() { reapers = map[string]*Reaper{} }()
+
+	t.Run("environment is set in the reaper container", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockReaperProvider{}
+
+		_, err := newReaper(context.Background(), "sessionId", provider, WithReaperEnv(map[string]string{
+			"RYUK_RECONNECTION_TIMEOUT": "5m",
+		}))
+		assert.EqualError(t, err, "expected")
+
+		assert.Equal(t, map[string]string{"RYUK_RECONNECTION_TIMEOUT": "5m"}, provider.req.Env)
+	})
+
+	t.Run("listening port cannot be overridden", func(t *testing.T) {
+		reapers = map[string]*Reaper{}
+		provider := &mockReaperProvider{}
+
+		_, err := newReaper(context.Background(), "sessionId", provider, WithReaperEnv(map[string]string{
+			"RYUK_PORT": "9090",
+		}))
+		assert.ErrorContains(t, err, "RYUK_PORT cannot be overridden")
+	})
+}
@@ -1,6 +1,7 @@
 package testcontainers
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/google/uuid"
@@ -9,6 +10,11 @@ import (
 var tcSessionID uuid.UUID
 var tcSessionIDOnce sync.Once
 
+// SessionID returns the ID of the current test session, which labels the resources to be cleaned up by the reaper
+func SessionID() string {
+	return sessionID().String()
+}
+
 func sessionID() uuid.UUID {
 	tcSessionIDOnce.Do(func() {
 		tcSessionID = uuid.New()
@@ -16,3 +22,14 @@ func sessionID() uuid.UUID {
 
 	return tcSessionID
 }
+
+// SessionName returns the given base name suffixed with the short ID of the current test session,
+// e.g. to name a container in a way which is stable within the session, but does not collide with
+// the containers of other sessions running in parallel on the same Docker host
+func SessionName(base string) string {
+	return sessionName(base, SessionID())
+}
+
+func sessionName(base string, sessionID string) string {
+	return fmt.Sprintf("%s-%s", base, sessionID[:8])
+}
@@ -0,0 +1,27 @@
+package testcontainers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionName(t *testing.T) {
+	t.Run("stable within the session", func(t *testing.T) {
+		name := SessionName("postgres")
+
+		assert.Equal(t, name, SessionName("postgres"))
+		assert.Equal(t, "postgres-"+SessionID()[:8], name)
+	})
+
+	t.Run("different between sessions", func(t *testing.T) {
+		first := sessionName("postgres", uuid.NewString())
+		second := sessionName("postgres", uuid.NewString())
+
+		assert.True(t, strings.HasPrefix(first, "postgres-"))
+		assert.True(t, strings.HasPrefix(second, "postgres-"))
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,113 @@
+package testcontainers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerStats is a sample of the resources used by a running container
+type ContainerStats struct {
+	Read time.Time // time at which the sample was read by the Docker daemon
+
+	CPUPercentage float64 // usage of the CPUs available to the container, up to 100% per CPU
+	MemoryUsage   uint64  // in bytes, excluding the page cache which can be reclaimed
+	MemoryLimit   uint64  // in bytes, the memory of the host when the container is not limited
+
+	// received and transmitted bytes on all the networks of the container since the previous sample,
+	// or since the container started for the first sample
+	NetworkRxBytes uint64
+	NetworkTxBytes uint64
+}
+
+// Stats streams samples of the resources used by the container, about once per second, until the context
+// is done or the container stops. The channel is closed when the stream ends
+func (c *DockerContainer) Stats(ctx context.Context) (<-chan ContainerStats, error) {
+	resp, err := c.provider.client.ContainerStats(ctx, c.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(chan ContainerStats)
+	go func() {
+		defer close(samples)
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		var previous *types.StatsJSON
+		for {
+			var stats types.StatsJSON
+			if err := decoder.Decode(&stats); err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					c.logger.Printf("cannot decode the stats of container %s: %v", c.ID, err)
+				}
+				return
+			}
+
+			// the Docker daemon keeps sending empty samples for a container which is not running
+			if stats.Read.IsZero() {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case samples <- newContainerStats(&stats, previous):
+			}
+			previous = &stats
+		}
+	}()
+
+	return samples, nil
+}
+
+// newContainerStats computes a sample as the Docker CLI does, the network IO being relative to the previous sample if any
+func newContainerStats(stats *types.StatsJSON, previous *types.StatsJSON) ContainerStats {
+	s := ContainerStats{
+		Read:        stats.Read,
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+
+	// the page cache is reported as inactive_file with cgroup v2, and as total_inactive_file with cgroup v1
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if cache, ok := stats.MemoryStats.Stats[key]; ok && cache < s.MemoryUsage {
+			s.MemoryUsage -= cache
+			break
+		}
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		s.CPUPercentage = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	rx, tx := networkIO(stats)
+	if previous != nil {
+		previousRx, previousTx := networkIO(previous)
+		// the counters are reset when the container is restarted
+		if rx >= previousRx && tx >= previousTx {
+			rx, tx = rx-previousRx, tx-previousTx
+		}
+	}
+	s.NetworkRxBytes, s.NetworkTxBytes = rx, tx
+
+	return s
+}
+
+func networkIO(stats *types.StatsJSON) (rx uint64, tx uint64) {
+	for _, network := range stats.Networks {
+		rx += network.RxBytes
+		tx += network.TxBytes
+	}
+	return rx, tx
+}
@@ -0,0 +1,109 @@
+package testcontainers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func Test_NewContainerStats(t *testing.T) {
+	read := time.Now()
+	stats := &types.StatsJSON{
+		Stats: types.Stats{
+			Read: read,
+			CPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 3000},
+				SystemUsage: 20000,
+				OnlineCPUs:  2,
+			},
+			PreCPUStats: types.CPUStats{
+				CPUUsage:    types.CPUUsage{TotalUsage: 1000},
+				SystemUsage: 10000,
+			},
+			MemoryStats: types.MemoryStats{
+				Usage: 64 * 1024 * 1024,
+				Limit: 128 * 1024 * 1024,
+				Stats: map[string]uint64{"inactive_file": 16 * 1024 * 1024},
+			},
+		},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 1000, TxBytes: 500},
+			"eth1": {RxBytes: 200, TxBytes: 100},
+		},
+	}
+
+	t.Run("first sample", func(t *testing.T) {
+		s := newContainerStats(stats, nil)
+
+		assert.Equal(t, read, s.Read)
+		assert.InDelta(t, 40.0, s.CPUPercentage, 0.001)
+		assert.Equal(t, uint64(48*1024*1024), s.MemoryUsage)
+		assert.Equal(t, uint64(128*1024*1024), s.MemoryLimit)
+		assert.Equal(t, uint64(1200), s.NetworkRxBytes)
+		assert.Equal(t, uint64(600), s.NetworkTxBytes)
+	})
+
+	t.Run("network IO since the previous sample", func(t *testing.T) {
+		previous := &types.StatsJSON{
+			Networks: map[string]types.NetworkStats{
+				"eth0": {RxBytes: 700, TxBytes: 400},
+				"eth1": {RxBytes: 100, TxBytes: 100},
+			},
+		}
+		s := newContainerStats(stats, previous)
+
+		assert.Equal(t, uint64(400), s.NetworkRxBytes)
+		assert.Equal(t, uint64(100), s.NetworkTxBytes)
+	})
+
+	t.Run("no CPU usage yet", func(t *testing.T) {
+		s := newContainerStats(&types.StatsJSON{}, nil)
+
+		assert.Zero(t, s.CPUPercentage)
+	})
+}
+
+func TestContainerStats(t *testing.T) {
+	ctx := context.Background()
+	req := ContainerRequest{
+		Image:        nginxAlpineImage,
+		ExposedPorts: []string{nginxDefaultPort},
+		WaitingFor:   wait.ForListeningPort(nginxDefaultPort),
+	}
+	container, err := GenericContainer(ctx, GenericContainerRequest{
+		ProviderType:     providerType,
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+	terminateContainerOnEnd(t, ctx, container)
+
+	statsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	samples, err := container.Stats(statsCtx)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case s, ok := <-samples:
+			require.True(t, ok, "the stats stream ended unexpectedly")
+			assert.NotZero(t, s.MemoryUsage)
+			assert.GreaterOrEqual(t, s.MemoryLimit, s.MemoryUsage)
+		case <-time.After(10 * time.Second):
+			t.Fatal("no stats received")
+		}
+	}
+
+	// the stream ends when the context is cancelled
+	cancel()
+	for range samples {
+	}
+}
@@ -11,9 +11,12 @@ var _ Strategy = (*MultiStrategy)(nil)
 var _ StrategyTimeout = (*MultiStrategy)(nil)
 
 type MultiStrategy struct {
+	startupDelay
+
 	// all Strategies should have a startupTimeout to avoid waiting infinitely
-	timeout  *time.Duration
-	deadline *time.Duration
+	timeout    *time.Duration
+	deadline   *time.Duration
+	deadlineAt *time.Time
 
 	// additional properties
 	Strategies []Strategy
@@ -38,6 +41,20 @@ func (ms *MultiStrategy) WithDeadline(deadline time.Duration) *MultiStrategy {
 	return ms
 }
 
+// WithDeadlineAt sets a point in time by which all wait strategies must be completed
+func (ms *MultiStrategy) WithDeadlineAt(deadline time.Time) *MultiStrategy {
+	ms.deadlineAt = &deadline
+	return ms
+}
+
+// WithStartupDelay sets the grace delay waited for once all the wait strategies are satisfied,
+// within the deadline
+func (ms *MultiStrategy) WithStartupDelay(delay time.Duration) *MultiStrategy {
+	ms.startupDelay.delay = delay
+	return ms
+}
+
+// ForAll runs the strategies sequentially, each one honoring its own startup timeout
 func ForAll(strategies ...Strategy) *MultiStrategy {
 	return &MultiStrategy{
 		Strategies: strategies,
@@ -55,12 +72,18 @@ func (ms *MultiStrategy) WaitUntilReady(ctx context.Context, target StrategyTarg
 		defer cancel()
 	}
 
+	if ms.deadlineAt != nil {
+		ctx, cancel = context.WithDeadline(ctx, *ms.deadlineAt)
+		defer cancel()
+	}
+
 	if len(ms.Strategies) == 0 {
 		return fmt.Errorf("no wait strategy supplied")
 	}
 
-	for _, strategy := range ms.Strategies {
+	for i, strategy := range ms.Strategies {
 		strategyCtx := ctx
+		start := time.Now()
 
 		// Set default Timeout when strategy implements StrategyTimeout
 		if st, ok := strategy.(StrategyTimeout); ok {
@@ -72,9 +95,9 @@ func (ms *MultiStrategy) WaitUntilReady(ctx context.Context, target StrategyTarg
 
 		err := strategy.WaitUntilReady(strategyCtx, target)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: wait strategy #%d (%T) failed after %s", err, i+1, strategy, time.Since(start).Round(time.Millisecond))
 		}
 	}
 
-	return nil
+	return ms.waitStartupDelay(ctx)
 }
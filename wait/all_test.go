@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 	"time"
 )
@@ -66,6 +67,24 @@ func TestMultiStrategy_WaitUntilReady(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "WithDeadlineAt sets context Deadline for WaitStrategy",
+			strategy: ForAll(
+				ForNop(
+					func(ctx context.Context, target StrategyTarget) error {
+						if _, set := ctx.Deadline(); !set {
+							return errors.New("expected context.Deadline to be set")
+						}
+						return nil
+					},
+				),
+			).WithDeadlineAt(time.Now().Add(1 * time.Second)),
+			args: args{
+				ctx:    context.Background(),
+				target: NopStrategyTarget{},
+			},
+			wantErr: false,
+		},
 		{
 			name: "WithStartupTimeoutDefault skips setting context.Deadline when WaitStrategy.Timeout is defined",
 			strategy: ForAll(
@@ -119,3 +138,25 @@ func TestMultiStrategy_WaitUntilReady(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiStrategy_ErrorIdentifiesTheFailingStrategy(t *testing.T) {
+	errIntentional := errors.New("intentional failure")
+
+	strategy := ForAll(
+		ForNop(func(ctx context.Context, target StrategyTarget) error {
+			return nil
+		}),
+		ForNop(func(ctx context.Context, target StrategyTarget) error {
+			return errIntentional
+		}),
+	)
+
+	err := strategy.WaitUntilReady(context.Background(), NopStrategyTarget{})
+	if !errors.Is(err, errIntentional) {
+		t.Fatalf("expected the strategy error to be wrapped, got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "wait strategy #2 (*wait.NopStrategy) failed after") {
+		t.Fatalf("expected the error to identify the failing strategy, got: %s", err)
+	}
+}
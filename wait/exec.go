@@ -10,6 +10,8 @@ var _ Strategy = (*ExecStrategy)(nil)
 var _ StrategyTimeout = (*ExecStrategy)(nil)
 
 type ExecStrategy struct {
+	startupDelay
+
 	// all Strategies should have a startupTimeout to avoid waiting infinitely
 	timeout *time.Duration
 	cmd     []string
@@ -49,6 +51,12 @@ func (ws *ExecStrategy) WithPollInterval(pollInterval time.Duration) *ExecStrate
 	return ws
 }
 
+// WithStartupDelay sets the grace delay waited for once the command exits with the expected code
+func (ws *ExecStrategy) WithStartupDelay(delay time.Duration) *ExecStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 // ForExec is a convenience method to assign ExecStrategy
 func ForExec(cmd []string) *ExecStrategy {
 	return NewExecStrategy(cmd)
@@ -67,11 +75,13 @@ func (ws *ExecStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start, attempts := time.Now(), 0
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		case <-time.After(ws.PollInterval):
+			attempts++
 			exitCode, _, err := target.Exec(ctx, ws.cmd)
 			if err != nil {
 				return err
@@ -80,7 +90,7 @@ func (ws *ExecStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 				continue
 			}
 
-			return nil
+			return ws.waitStartupDelay(ctx)
 		}
 	}
 }
@@ -122,11 +122,38 @@ func TestExecStrategyWaitUntilReady_DeadlineExceeded(t *testing.T) {
 	}
 	wg := wait.NewExecStrategy([]string{"true"})
 	err := wg.WaitUntilReady(ctx, target)
-	if err != context.DeadlineExceeded {
+	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatal(err)
 	}
 }
 
+func TestExecStrategyWaitUntilReady_StartupTimeoutError(t *testing.T) {
+	target := mockExecTarget{
+		exitCode: 1,
+	}
+	wg := wait.NewExecStrategy([]string{"false"}).
+		WithStartupTimeout(500 * time.Millisecond).
+		WithPollInterval(50 * time.Millisecond)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err == nil {
+		t.Fatal("expected the strategy to time out")
+	}
+	if err.Error() != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the error message to be unchanged, got %q", err)
+	}
+
+	var timeoutErr *wait.StartupTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a StartupTimeoutError, got %T", err)
+	}
+	if timeoutErr.Elapsed() < 500*time.Millisecond {
+		t.Fatalf("expected at least 500ms to elapse, got %s", timeoutErr.Elapsed())
+	}
+	if timeoutErr.Attempts() == 0 {
+		t.Fatal("expected at least one attempt")
+	}
+}
+
 func TestExecStrategyWaitUntilReady_CustomExitCode(t *testing.T) {
 	target := mockExecTarget{
 		exitCode: 10,
@@ -2,6 +2,8 @@ package wait
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -10,13 +12,21 @@ import (
 var _ Strategy = (*ExitStrategy)(nil)
 var _ StrategyTimeout = (*ExitStrategy)(nil)
 
+// ErrUnexpectedExitCode is returned when the container exits with another exit code than the expected one
+var ErrUnexpectedExitCode = errors.New("unexpected exit code")
+
 // ExitStrategy will wait until container exit
 type ExitStrategy struct {
+	startupDelay
+
 	// all Strategies should have a timeout to avoid waiting infinitely
 	timeout *time.Duration
 
 	// additional properties
 	PollInterval time.Duration
+
+	// the exit code the container must exit with, any exit code is accepted when nil
+	exitCode *int
 }
 
 // NewExitStrategy constructs with polling interval of 100 milliseconds without timeout by default
@@ -43,6 +53,19 @@ func (ws *ExitStrategy) WithPollInterval(pollInterval time.Duration) *ExitStrate
 	return ws
 }
 
+// WithExitCode can be used to check the exit code of the container, e.g. for a one-shot job which must succeed
+func (ws *ExitStrategy) WithExitCode(exitCode int) *ExitStrategy {
+	ws.exitCode = &exitCode
+	return ws
+}
+
+// WithStartupDelay sets the grace delay waited for once the container exited, e.g. for the services of a sidecar
+// to settle once its setup job is done
+func (ws *ExitStrategy) WithStartupDelay(delay time.Duration) *ExitStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 // ForExit is the default construction for the fluid interface.
 //
 // For Example:
@@ -66,24 +89,32 @@ func (ws *ExitStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 		defer cancel()
 	}
 
+	start, attempts := time.Now(), 0
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		default:
+			attempts++
 			state, err := target.State(ctx)
 			if err != nil {
 				if !strings.Contains(err.Error(), "No such container") {
 					return err
+				} else if ws.exitCode != nil {
+					// e.g. an auto removed container
+					return fmt.Errorf("%w: the container was removed before its exit code could be checked", ErrUnexpectedExitCode)
 				} else {
-					return nil
+					return ws.waitStartupDelay(ctx)
 				}
 			}
 			if state.Running {
-				time.Sleep(ws.PollInterval)
+				sleepContext(ctx, ws.PollInterval)
 				continue
 			}
-			return nil
+			if ws.exitCode != nil && state.ExitCode != *ws.exitCode {
+				return fmt.Errorf("%w: %d, expected %d", ErrUnexpectedExitCode, state.ExitCode, *ws.exitCode)
+			}
+			return ws.waitStartupDelay(ctx)
 		}
 	}
 }
@@ -2,6 +2,7 @@ package wait
 
 import (
 	"context"
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 
 type exitStrategyTarget struct {
 	isRunning bool
+	exitCode  int
 }
 
 func (st exitStrategyTarget) Host(ctx context.Context) (string, error) {
@@ -36,7 +38,7 @@ func (st exitStrategyTarget) Exec(ctx context.Context, cmd []string, options ...
 }
 
 func (st exitStrategyTarget) State(ctx context.Context) (*types.ContainerState, error) {
-	return &types.ContainerState{Running: st.isRunning}, nil
+	return &types.ContainerState{Running: st.isRunning, ExitCode: st.exitCode}, nil
 }
 
 func TestWaitForExit(t *testing.T) {
@@ -49,3 +51,30 @@ func TestWaitForExit(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestWaitForExitWithExitCode(t *testing.T) {
+	target := exitStrategyTarget{
+		isRunning: false,
+		exitCode:  0,
+	}
+	wg := NewExitStrategy().WithExitTimeout(100 * time.Millisecond).WithExitCode(0)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForExitWithUnexpectedExitCode(t *testing.T) {
+	target := exitStrategyTarget{
+		isRunning: false,
+		exitCode:  2,
+	}
+	wg := NewExitStrategy().WithExitTimeout(100 * time.Millisecond).WithExitCode(0)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if !errors.Is(err, ErrUnexpectedExitCode) {
+		t.Fatalf("expected %v, got %v", ErrUnexpectedExitCode, err)
+	}
+	if err.Error() != "unexpected exit code: 2, expected 0" {
+		t.Fatalf("the error should contain the actual exit code, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// Implement interface
+var _ Strategy = (*FileStrategy)(nil)
+var _ StrategyTimeout = (*FileStrategy)(nil)
+
+// ErrFileCopyNotSupported is returned by ForFile when the target does not implement FileStrategyTarget
+var ErrFileCopyNotSupported = errors.New("target does not support copying files from the container")
+
+// FileStrategy will wait until a given file exists in the container, e.g. a sentinel file created by an init process
+type FileStrategy struct {
+	startupDelay
+
+	// all Strategies should have a startupTimeout to avoid waiting infinitely
+	timeout *time.Duration
+
+	// additional properties
+	File         string
+	Matcher      func(content []byte) bool
+	PollInterval time.Duration
+}
+
+// NewFileStrategy constructs with polling interval of 100 milliseconds and startup timeout of 60 seconds by default
+func NewFileStrategy(file string) *FileStrategy {
+	return &FileStrategy{
+		File:         file,
+		PollInterval: defaultPollInterval(),
+	}
+}
+
+// fluent builders for each property
+// since go has neither covariance nor generics, the return type must be the type of the concrete implementation
+// this is true for all properties, even the "shared" ones like startupTimeout
+
+// WithStartupTimeout can be used to change the default startup timeout
+func (ws *FileStrategy) WithStartupTimeout(startupTimeout time.Duration) *FileStrategy {
+	ws.timeout = &startupTimeout
+	return ws
+}
+
+// WithPollInterval can be used to override the default polling interval of 100 milliseconds
+func (ws *FileStrategy) WithPollInterval(pollInterval time.Duration) *FileStrategy {
+	ws.PollInterval = pollInterval
+	return ws
+}
+
+// WithStartupDelay sets the grace delay waited for once the file exists
+func (ws *FileStrategy) WithStartupDelay(delay time.Duration) *FileStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
+// WithMatcher can be used to also wait until the content of the file, copied from the container, is matched
+func (ws *FileStrategy) WithMatcher(matcher func(content []byte) bool) *FileStrategy {
+	ws.Matcher = matcher
+	return ws
+}
+
+// ForFile is the default construction for the fluid interface.
+//
+// For Example:
+//
+//	wait.
+//		ForFile("/tmp/ready").
+//		WithPollInterval(1 * time.Second)
+func ForFile(file string) *FileStrategy {
+	return NewFileStrategy(file)
+}
+
+func (ws *FileStrategy) Timeout() *time.Duration {
+	return ws.timeout
+}
+
+// WaitUntilReady implements Strategy.WaitUntilReady
+func (ws *FileStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget) error {
+	fileTarget, ok := target.(FileStrategyTarget)
+	if !ok {
+		return fmt.Errorf("%w: waiting for file %s with a %T", ErrFileCopyNotSupported, ws.File, target)
+	}
+
+	timeout := defaultStartupTimeout()
+	if ws.timeout != nil {
+		timeout = *ws.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start, attempts := time.Now(), 0
+	for {
+		select {
+		case <-ctx.Done():
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
+		default:
+			attempts++
+			ready, err := ws.check(ctx, fileTarget)
+			if err != nil {
+				return err
+			}
+			if !ready {
+				sleepContext(ctx, ws.PollInterval)
+				continue
+			}
+			return ws.waitStartupDelay(ctx)
+		}
+	}
+}
+
+// check copies the file from the container, matching its content when a matcher is set.
+// A file which does not exist yet is not an error.
+func (ws *FileStrategy) check(ctx context.Context, target FileStrategyTarget) (bool, error) {
+	reader, err := target.CopyFileFromContainer(ctx, ws.File)
+	if err != nil {
+		if errdefs.IsNotFound(err) || ctx.Err() != nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: copying file %s from the container", err, ws.File)
+	}
+	defer reader.Close()
+
+	if ws.Matcher == nil {
+		return true, nil
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return false, nil
+	}
+
+	return ws.Matcher(content), nil
+}
@@ -0,0 +1,79 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fileStrategyTarget returns the content of a file being written by the container, one copy at a time
+type fileStrategyTarget struct {
+	NopStrategyTarget
+	contents []string
+	copies   *int32
+}
+
+func (st fileStrategyTarget) CopyFileFromContainer(_ context.Context, _ string) (io.ReadCloser, error) {
+	i := int(atomic.AddInt32(st.copies, 1)) - 1
+	if i >= len(st.contents) {
+		i = len(st.contents) - 1
+	}
+	return io.NopCloser(bytes.NewReader([]byte(st.contents[i]))), nil
+}
+
+func TestWaitForFile(t *testing.T) {
+	target := NopStrategyTarget{
+		Files: map[string][]byte{"/tmp/ready": nil},
+	}
+	wg := ForFile("/tmp/ready").WithStartupTimeout(2 * time.Second)
+
+	if err := wg.WaitUntilReady(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForFileButItWillNeverExist(t *testing.T) {
+	wg := ForFile("/tmp/ready").
+		WithStartupTimeout(200 * time.Millisecond).
+		WithPollInterval(10 * time.Millisecond)
+
+	err := wg.WaitUntilReady(context.Background(), NopStrategyTarget{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the startup timeout to be exceeded, got %v", err)
+	}
+}
+
+func TestWaitForFileWithoutFileCopy(t *testing.T) {
+	// a target implemented outside of this package may not copy files from the container
+	target := struct{ StrategyTarget }{NopStrategyTarget{Files: map[string][]byte{"/tmp/ready": nil}}}
+
+	err := ForFile("/tmp/ready").WaitUntilReady(context.Background(), target)
+	if !errors.Is(err, ErrFileCopyNotSupported) {
+		t.Fatalf("expected the file copy not to be supported, got %v", err)
+	}
+}
+
+func TestWaitForFileWithMatcher(t *testing.T) {
+	target := fileStrategyTarget{
+		contents: []string{"", "starting", "ready"},
+		copies:   new(int32),
+	}
+	wg := ForFile("/tmp/status").
+		WithMatcher(func(content []byte) bool {
+			return strings.TrimSpace(string(content)) == "ready"
+		}).
+		WithStartupTimeout(2 * time.Second).
+		WithPollInterval(10 * time.Millisecond)
+
+	if err := wg.WaitUntilReady(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+	if copies := atomic.LoadInt32(target.copies); copies != 3 {
+		t.Fatalf("expected the file to be copied 3 times, got %d", copies)
+	}
+}
@@ -2,15 +2,23 @@ package wait
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/docker/docker/api/types"
 )
 
 // Implement interface
 var _ Strategy = (*HealthStrategy)(nil)
 var _ StrategyTimeout = (*HealthStrategy)(nil)
 
+// ErrNoHealthCheck is returned when waiting for a container that defines no health check
+var ErrNoHealthCheck = errors.New("container has no health check defined")
+
 // HealthStrategy will wait until the container becomes healthy
 type HealthStrategy struct {
+	startupDelay
+
 	// all Strategies should have a startupTimeout to avoid waiting infinitely
 	timeout *time.Duration
 
@@ -42,6 +50,12 @@ func (ws *HealthStrategy) WithPollInterval(pollInterval time.Duration) *HealthSt
 	return ws
 }
 
+// WithStartupDelay sets the grace delay waited for once the container is healthy
+func (ws *HealthStrategy) WithStartupDelay(delay time.Duration) *HealthStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 // ForHealthCheck is the default construction for the fluid interface.
 //
 // For Example:
@@ -67,20 +81,26 @@ func (ws *HealthStrategy) WaitUntilReady(ctx context.Context, target StrategyTar
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start, attempts := time.Now(), 0
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		default:
+			attempts++
 			state, err := target.State(ctx)
 			if err != nil {
 				return err
 			}
-			if state.Health.Status != "healthy" {
-				time.Sleep(ws.PollInterval)
+			// Docker reports a health status as soon as the container starts, if the image defines a HEALTHCHECK
+			if state.Health == nil {
+				return ErrNoHealthCheck
+			}
+			if state.Health.Status != types.Healthy {
+				sleepContext(ctx, ws.PollInterval)
 				continue
 			}
-			return nil
+			return ws.waitStartupDelay(ctx)
 		}
 	}
 }
@@ -0,0 +1,72 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// healthStrategyTarget reports the given health statuses, one per call, repeating the last one
+type healthStrategyTarget struct {
+	NopStrategyTarget
+	mx       sync.Mutex
+	statuses []*types.Health
+}
+
+func (st *healthStrategyTarget) State(_ context.Context) (*types.ContainerState, error) {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	health := st.statuses[0]
+	if len(st.statuses) > 1 {
+		st.statuses = st.statuses[1:]
+	}
+
+	return &types.ContainerState{Running: true, Health: health}, nil
+}
+
+func TestWaitForHealthCheck(t *testing.T) {
+	target := &healthStrategyTarget{
+		statuses: []*types.Health{
+			{Status: types.Starting},
+			{Status: types.Starting},
+			{Status: types.Healthy},
+		},
+	}
+
+	wg := ForHealthCheck().WithStartupTimeout(5 * time.Second).WithPollInterval(10 * time.Millisecond)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForHealthCheckWhenUnhealthy(t *testing.T) {
+	target := &healthStrategyTarget{
+		statuses: []*types.Health{
+			{Status: types.Unhealthy},
+		},
+	}
+
+	wg := ForHealthCheck().WithStartupTimeout(100 * time.Millisecond).WithPollInterval(10 * time.Millisecond)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline to be exceeded, got: %v", err)
+	}
+}
+
+func TestWaitForHealthCheckWithoutHealthCheck(t *testing.T) {
+	target := &healthStrategyTarget{
+		statuses: []*types.Health{nil},
+	}
+
+	wg := ForHealthCheck().WithStartupTimeout(5 * time.Second)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if !errors.Is(err, ErrNoHealthCheck) {
+		t.Fatalf("expected no health check error, got: %v", err)
+	}
+}
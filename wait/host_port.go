@@ -17,6 +17,8 @@ var _ Strategy = (*HostPortStrategy)(nil)
 var _ StrategyTimeout = (*HostPortStrategy)(nil)
 
 type HostPortStrategy struct {
+	startupDelay
+
 	// Port is a string containing port number and protocol in the format "80/tcp"
 	// which
 	Port nat.Port
@@ -61,6 +63,12 @@ func (hp *HostPortStrategy) WithPollInterval(pollInterval time.Duration) *HostPo
 	return hp
 }
 
+// WithStartupDelay sets the grace delay waited for once the port is listening
+func (hp *HostPortStrategy) WithStartupDelay(delay time.Duration) *HostPortStrategy {
+	hp.startupDelay.delay = delay
+	return hp
+}
+
 func (hp *HostPortStrategy) Timeout() *time.Duration {
 	return hp.timeout
 }
@@ -75,6 +83,8 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start := time.Now()
+
 	ipAddress, err := target.Host(ctx)
 	if err != nil {
 		return
@@ -111,7 +121,7 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("%s:%w", ctx.Err(), err)
+			return newStartupTimeoutError(fmt.Errorf("%s:%w", ctx.Err(), err), start, i)
 		case <-time.After(waitInterval):
 			port, err = target.MappedPort(ctx, internalPort)
 			if err != nil {
@@ -128,12 +138,16 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 	dialer := net.Dialer{}
 	address := net.JoinHostPort(ipAddress, portString)
 	for {
+		i++
 		conn, err := dialer.DialContext(ctx, proto, address)
 		if err != nil {
+			if ctx.Err() != nil {
+				return newStartupTimeoutError(ctx.Err(), start, i)
+			}
 			if v, ok := err.(*net.OpError); ok {
 				if v2, ok := (v.Err).(*os.SyscallError); ok {
 					if isConnRefusedErr(v2.Err) {
-						time.Sleep(waitInterval)
+						sleepContext(ctx, waitInterval)
 						continue
 					}
 				}
@@ -148,8 +162,9 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 	//internal check
 	command := buildInternalCheckCommand(internalPort.Int())
 	for {
+		i++
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, i)
 		}
 		exitCode, _, err := target.Exec(ctx, []string{"/bin/sh", "-c", command})
 		if err != nil {
@@ -161,9 +176,10 @@ func (hp *HostPortStrategy) WaitUntilReady(ctx context.Context, target StrategyT
 		} else if exitCode == 126 {
 			return errors.New("/bin/sh command not executable")
 		}
+		sleepContext(ctx, waitInterval)
 	}
 
-	return nil
+	return hp.waitStartupDelay(ctx)
 }
 
 func buildInternalCheckCommand(internalPort int) string {
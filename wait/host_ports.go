@@ -0,0 +1,94 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// Implement interface
+var _ Strategy = (*HostPortsStrategy)(nil)
+var _ StrategyTimeout = (*HostPortsStrategy)(nil)
+
+// HostPortsStrategy will wait until all the given ports are listening
+type HostPortsStrategy struct {
+	startupDelay
+
+	// all WaitStrategies should have a startupTimeout to avoid waiting infinitely
+	timeout *time.Duration
+
+	// additional properties
+	Ports        []nat.Port
+	PollInterval time.Duration
+}
+
+// NewHostPortsStrategy constructs a strategy waiting for all the given ports,
+// with polling interval of 100 milliseconds and startup timeout of 60 seconds by default
+func NewHostPortsStrategy(ports ...nat.Port) *HostPortsStrategy {
+	return &HostPortsStrategy{
+		Ports:        ports,
+		PollInterval: defaultPollInterval(),
+	}
+}
+
+// fluent builders for each property
+// since go has neither covariance nor generics, the return type must be the type of the concrete implementation
+// this is true for all properties, even the "shared" ones like startupTimeout
+
+// ForListeningPorts waits until every given port accepts connections, e.g. the control and data ports of a broker.
+// ForListeningPort is the single port counterpart
+func ForListeningPorts(ports ...nat.Port) *HostPortsStrategy {
+	return NewHostPortsStrategy(ports...)
+}
+
+// WithStartupTimeout can be used to change the default startup timeout, which applies to all the ports
+func (hp *HostPortsStrategy) WithStartupTimeout(startupTimeout time.Duration) *HostPortsStrategy {
+	hp.timeout = &startupTimeout
+	return hp
+}
+
+// WithPollInterval can be used to override the default polling interval of 100 milliseconds
+func (hp *HostPortsStrategy) WithPollInterval(pollInterval time.Duration) *HostPortsStrategy {
+	hp.PollInterval = pollInterval
+	return hp
+}
+
+// WithStartupDelay sets the grace delay waited for once all the ports are listening
+func (hp *HostPortsStrategy) WithStartupDelay(delay time.Duration) *HostPortsStrategy {
+	hp.startupDelay.delay = delay
+	return hp
+}
+
+func (hp *HostPortsStrategy) Timeout() *time.Duration {
+	return hp.timeout
+}
+
+// WaitUntilReady implements Strategy.WaitUntilReady
+func (hp *HostPortsStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget) error {
+	timeout := defaultStartupTimeout()
+	if hp.timeout != nil {
+		timeout = *hp.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if len(hp.Ports) == 0 {
+		return fmt.Errorf("no port to wait for")
+	}
+
+	for _, port := range hp.Ports {
+		// the startup timeout is shared by all the ports through the context
+		strategy := NewHostPortStrategy(port).
+			WithStartupTimeout(timeout).
+			WithPollInterval(hp.PollInterval)
+
+		if err := strategy.WaitUntilReady(ctx, target); err != nil {
+			return fmt.Errorf("%w: port %s is not listening", err, port)
+		}
+	}
+
+	return hp.waitStartupDelay(ctx)
+}
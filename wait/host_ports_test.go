@@ -0,0 +1,107 @@
+package wait
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+func listeningPort(t *testing.T) nat.Port {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return nat.Port(port + "/tcp")
+}
+
+func closedPort(t *testing.T) nat.Port {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return nat.Port(port + "/tcp")
+}
+
+func TestWaitForListeningPorts(t *testing.T) {
+	wg := ForListeningPorts(listeningPort(t), listeningPort(t)).
+		WithStartupTimeout(5 * time.Second)
+
+	err := wg.WaitUntilReady(context.Background(), NopStrategyTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForListeningPortsReportsTheMissingPort(t *testing.T) {
+	missing := closedPort(t)
+
+	wg := ForListeningPorts(listeningPort(t), missing).
+		WithStartupTimeout(500 * time.Millisecond)
+
+	err := wg.WaitUntilReady(context.Background(), NopStrategyTarget{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !strings.Contains(err.Error(), "port "+string(missing)+" is not listening") {
+		t.Fatalf("expected the error to report port %s, got: %s", missing, err)
+	}
+}
+
+// remoteHostTarget exposes its ports on a host other than localhost
+type remoteHostTarget struct {
+	NopStrategyTarget
+	host string
+}
+
+func (st remoteHostTarget) Host(_ context.Context) (string, error) {
+	return st.host, nil
+}
+
+func TestWaitForListeningPortDialsTheTargetHost(t *testing.T) {
+	// 127.0.0.2 is a loopback address that is not localhost, as a remote Docker host would be
+	listener, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("cannot listen on 127.0.0.2: %s", err)
+	}
+	defer listener.Close()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := ForListeningPort(nat.Port(port + "/tcp")).WithStartupTimeout(2 * time.Second)
+
+	err = wg.WaitUntilReady(context.Background(), remoteHostTarget{host: "127.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/docker/go-connections/nat"
@@ -20,6 +22,8 @@ var _ Strategy = (*HTTPStrategy)(nil)
 var _ StrategyTimeout = (*HTTPStrategy)(nil)
 
 type HTTPStrategy struct {
+	startupDelay
+
 	// all Strategies should have a startupTimeout to avoid waiting infinitely
 	timeout *time.Duration
 
@@ -80,6 +84,32 @@ func (ws *HTTPStrategy) WithResponseMatcher(matcher func(body io.Reader) bool) *
 	return ws
 }
 
+// WithJSONPathMatcher matches the response when the field found at the given dot-separated path
+// of the JSON body has the expected value, e.g. WithJSONPathMatcher("status", "UP") for {"status":"UP"}
+func (ws *HTTPStrategy) WithJSONPathMatcher(path string, expected string) *HTTPStrategy {
+	ws.ResponseMatcher = func(body io.Reader) bool {
+		var value interface{}
+		if err := json.NewDecoder(body).Decode(&value); err != nil {
+			return false
+		}
+
+		for _, field := range strings.Split(path, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+
+			value, ok = object[field]
+			if !ok {
+				return false
+			}
+		}
+
+		return fmt.Sprintf("%v", value) == expected
+	}
+	return ws
+}
+
 func (ws *HTTPStrategy) WithTLS(useTLS bool, tlsconf ...*tls.Config) *HTTPStrategy {
 	ws.UseTLS = useTLS
 	if useTLS && len(tlsconf) > 0 {
@@ -88,6 +118,16 @@ func (ws *HTTPStrategy) WithTLS(useTLS bool, tlsconf ...*tls.Config) *HTTPStrate
 	return ws
 }
 
+// WithTLSConfig enables HTTPS, polling with a client configured with the given TLS config,
+// e.g. to trust the CA which signed the certificate of the container.
+// The config is only used by the wait strategy, and it is not modified.
+func (ws *HTTPStrategy) WithTLSConfig(config *tls.Config) *HTTPStrategy {
+	ws.UseTLS = true
+	ws.TLSConfig = config
+	return ws
+}
+
+// WithAllowInsecure skips the verification of the certificate presented by the container when polling with HTTPS
 func (ws *HTTPStrategy) WithAllowInsecure(allowInsecure bool) *HTTPStrategy {
 	ws.AllowInsecure = allowInsecure
 	return ws
@@ -109,6 +149,12 @@ func (ws *HTTPStrategy) WithPollInterval(pollInterval time.Duration) *HTTPStrate
 	return ws
 }
 
+// WithStartupDelay sets the grace delay waited for once the response is matched
+func (ws *HTTPStrategy) WithStartupDelay(delay time.Duration) *HTTPStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 // ForHTTP is a convenience method similar to Wait.java
 // https://github.com/testcontainers/testcontainers-java/blob/1d85a3834bd937f80aad3a4cec249c027f31aeb4/core/src/main/java/org/testcontainers/containers/wait/strategy/Wait.java
 func ForHTTP(path string) *HTTPStrategy {
@@ -129,6 +175,8 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start, attempts := time.Now(), 0
+
 	ipAddress, err := target.Host(ctx)
 	if err != nil {
 		return
@@ -140,8 +188,9 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 	for port == "" {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("%s:%w", ctx.Err(), err)
+			return newStartupTimeoutError(fmt.Errorf("%s:%w", ctx.Err(), err), start, attempts)
 		case <-time.After(ws.PollInterval):
+			attempts++
 			port, err = target.MappedPort(ctx, ws.Port)
 		}
 	}
@@ -173,18 +222,18 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig:       ws.TLSConfig,
 	}
 
 	var proto string
 	if ws.UseTLS {
 		proto = "https"
+		// the config is cloned so that skipping the verification does not leak out of the wait strategy
+		tripper.TLSClientConfig = ws.TLSConfig.Clone()
 		if ws.AllowInsecure {
-			if ws.TLSConfig == nil {
-				tripper.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-			} else {
-				ws.TLSConfig.InsecureSkipVerify = true
+			if tripper.TLSClientConfig == nil {
+				tripper.TLSClientConfig = &tls.Config{}
 			}
+			tripper.TLSClientConfig.InsecureSkipVerify = true
 		}
 	} else {
 		proto = "http"
@@ -206,8 +255,9 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		case <-time.After(ws.PollInterval):
+			attempts++
 			req, err := http.NewRequestWithContext(ctx, ws.Method, endpoint, bytes.NewReader(body))
 			if err != nil {
 				return err
@@ -227,7 +277,7 @@ func (ws *HTTPStrategy) WaitUntilReady(ctx context.Context, target StrategyTarge
 			if err := resp.Body.Close(); err != nil {
 				continue
 			}
-			return nil
+			return ws.waitStartupDelay(ctx)
 		}
 	}
 }
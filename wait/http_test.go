@@ -10,11 +10,15 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/docker/go-connections/nat"
+
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -134,3 +138,100 @@ func TestHTTPStrategyWaitUntilReady(t *testing.T) {
 		return
 	}
 }
+
+func TestHTTPStrategyWaitUntilReadyWithJSONPathMatcher(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			_, _ = w.Write([]byte(`{"status":"DOWN","components":{"db":{"status":"DOWN"}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"UP","components":{"db":{"status":"UP"}}}`))
+		}
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := wait.ForHTTP("/health").
+		WithPort(nat.Port(port+"/tcp")).
+		WithJSONPathMatcher("components.db.status", "UP").
+		WithStartupTimeout(5 * time.Second)
+
+	err = wg.WaitUntilReady(context.Background(), wait.NopStrategyTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c := atomic.LoadInt32(&calls); c != 3 {
+		t.Fatalf("expected 3 calls to the health endpoint, got %d", c)
+	}
+}
+
+// tlsStrategyTarget exposes its ports on 127.0.0.1, the address in the certificate of httptest servers
+type tlsStrategyTarget struct {
+	wait.NopStrategyTarget
+}
+
+func (st tlsStrategyTarget) Host(_ context.Context) (string, error) {
+	return "127.0.0.1", nil
+}
+
+func TestHTTPStrategyWaitUntilReadyWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// trust the self-signed certificate of the server
+	certpool := x509.NewCertPool()
+	certpool.AddCert(server.Certificate())
+
+	wg := wait.ForHTTP("/ping").
+		WithPort(nat.Port(port + "/tcp")).
+		WithTLSConfig(&tls.Config{RootCAs: certpool}).
+		WithStartupTimeout(5 * time.Second)
+
+	err = wg.WaitUntilReady(context.Background(), tlsStrategyTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPStrategyWaitUntilReadyWithAllowInsecure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsconfig := &tls.Config{}
+	wg := wait.ForHTTP("/ping").
+		WithPort(nat.Port(port + "/tcp")).
+		WithTLSConfig(tlsconfig).
+		WithAllowInsecure(true).
+		WithStartupTimeout(5 * time.Second)
+
+	err = wg.WaitUntilReady(context.Background(), tlsStrategyTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tlsconfig.InsecureSkipVerify {
+		t.Fatal("expected the TLS config not to be modified by the wait strategy")
+	}
+}
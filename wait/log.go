@@ -2,7 +2,9 @@ package wait
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -13,11 +15,14 @@ var _ StrategyTimeout = (*LogStrategy)(nil)
 
 // LogStrategy will wait until a given log entry shows up in the docker logs
 type LogStrategy struct {
+	startupDelay
+
 	// all Strategies should have a startupTimeout to avoid waiting infinitely
 	timeout *time.Duration
 
 	// additional properties
 	Log          string
+	IsRegexp     bool
 	Occurrence   int
 	PollInterval time.Duration
 }
@@ -35,6 +40,12 @@ func NewLogStrategy(log string) *LogStrategy {
 // since go has neither covariance nor generics, the return type must be the type of the concrete implementation
 // this is true for all properties, even the "shared" ones like startupTimeout
 
+// AsRegexp can be used to change the default behavior of the Log field to be evaluated as a regular expression
+func (ws *LogStrategy) AsRegexp() *LogStrategy {
+	ws.IsRegexp = true
+	return ws
+}
+
 // WithStartupTimeout can be used to change the default startup timeout
 func (ws *LogStrategy) WithStartupTimeout(timeout time.Duration) *LogStrategy {
 	ws.timeout = &timeout
@@ -47,6 +58,12 @@ func (ws *LogStrategy) WithPollInterval(pollInterval time.Duration) *LogStrategy
 	return ws
 }
 
+// WithStartupDelay sets the grace delay waited for once the log is found
+func (ws *LogStrategy) WithStartupDelay(delay time.Duration) *LogStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 func (ws *LogStrategy) WithOccurrence(o int) *LogStrategy {
 	// the number of occurrence needs to be positive
 	if o <= 0 {
@@ -81,33 +98,49 @@ func (ws *LogStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	count := func(logs string) int {
+		return strings.Count(logs, ws.Log)
+	}
+	if ws.IsRegexp {
+		re, err := regexp.Compile(ws.Log)
+		if err != nil {
+			return fmt.Errorf("%w: invalid log regular expression", err)
+		}
+		count = func(logs string) int {
+			return len(re.FindAllString(logs, -1))
+		}
+	}
+
+	start, attempts := time.Now(), 0
 LOOP:
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		default:
+			attempts++
 			reader, err := target.Logs(ctx)
 			if err != nil {
-				time.Sleep(ws.PollInterval)
+				sleepContext(ctx, ws.PollInterval)
 				continue
 			}
 
 			b, err := io.ReadAll(reader)
 			if err != nil {
-				time.Sleep(ws.PollInterval)
+				sleepContext(ctx, ws.PollInterval)
 				continue
 			}
 
+			// the whole logs are read at once, so that a match cannot be split across chunks
 			logs := string(b)
-			if strings.Count(logs, ws.Log) >= ws.Occurrence {
+			if count(logs) >= ws.Occurrence {
 				break LOOP
 			} else {
-				time.Sleep(ws.PollInterval)
+				sleepContext(ctx, ws.PollInterval)
 				continue
 			}
 		}
 	}
 
-	return nil
+	return ws.waitStartupDelay(ctx)
 }
@@ -57,3 +57,42 @@ func TestWaitShouldFailWithExactNumberOfOccurrences(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestWaitForLogAsRegexp(t *testing.T) {
+	target := NopStrategyTarget{
+		ReaderCloser: io.NopCloser(bytes.NewReader([]byte("started replica 1\nstarted replica 2\nstarted replica 3\n"))),
+	}
+	wg := ForLog(`started replica \d`).
+		AsRegexp().
+		WithStartupTimeout(100 * time.Millisecond).
+		WithOccurrence(3)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForLogAsRegexpButItWillNeverHappen(t *testing.T) {
+	target := NopStrategyTarget{
+		ReaderCloser: io.NopCloser(bytes.NewReader([]byte("started replica 1\nstarted replica 2\n"))),
+	}
+	wg := ForLog(`started replica \d`).
+		AsRegexp().
+		WithStartupTimeout(100 * time.Millisecond).
+		WithOccurrence(3)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWaitForLogWithInvalidRegexp(t *testing.T) {
+	target := NopStrategyTarget{
+		ReaderCloser: io.NopCloser(bytes.NewReader([]byte("docker"))),
+	}
+	wg := ForLog(`docker(`).AsRegexp().WithStartupTimeout(100 * time.Millisecond)
+	err := wg.WaitUntilReady(context.Background(), target)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
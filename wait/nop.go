@@ -1,25 +1,35 @@
 package wait
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
 	"github.com/testcontainers/testcontainers-go/exec"
 )
 
 var _ Strategy = (*NopStrategy)(nil)
 var _ StrategyTimeout = (*NopStrategy)(nil)
+var _ FileStrategyTarget = NopStrategyTarget{}
 
+// NopStrategy is a strategy which does not wait for the container, only running the given functions, if any
 type NopStrategy struct {
+	startupDelay
+
 	timeout        *time.Duration
-	waitUntilReady func(context.Context, StrategyTarget) error
+	waitUntilReady []func(context.Context, StrategyTarget) error
 }
 
+// ForNop returns a strategy running the given functions in order, until one of them fails.
+// Without function, the container is ready immediately, e.g. for a static file server:
+// this makes the intent explicit, compared to a request without WaitingFor
 func ForNop(
-	waitUntilReady func(context.Context, StrategyTarget) error,
+	waitUntilReady ...func(context.Context, StrategyTarget) error,
 ) *NopStrategy {
 	return &NopStrategy{
 		waitUntilReady: waitUntilReady,
@@ -35,13 +45,27 @@ func (ws *NopStrategy) WithStartupTimeout(timeout time.Duration) *NopStrategy {
 	return ws
 }
 
+// WithStartupDelay sets the grace delay waited for once the functions succeeded, e.g. without function,
+// for a service which is not checked but known to be ready a moment after it starts
+func (ws *NopStrategy) WithStartupDelay(delay time.Duration) *NopStrategy {
+	ws.startupDelay.delay = delay
+	return ws
+}
+
 func (ws *NopStrategy) WaitUntilReady(ctx context.Context, target StrategyTarget) error {
-	return ws.waitUntilReady(ctx, target)
+	for _, waitUntilReady := range ws.waitUntilReady {
+		if err := waitUntilReady(ctx, target); err != nil {
+			return err
+		}
+	}
+	return ws.waitStartupDelay(ctx)
 }
 
 type NopStrategyTarget struct {
 	ReaderCloser   io.ReadCloser
 	ContainerState types.ContainerState
+	// Files are returned by CopyFileFromContainer, which fails with a not found error for any other path
+	Files map[string][]byte
 }
 
 func (st NopStrategyTarget) Host(_ context.Context) (string, error) {
@@ -67,3 +91,11 @@ func (st NopStrategyTarget) Exec(_ context.Context, _ []string, _ ...exec.Proces
 func (st NopStrategyTarget) State(_ context.Context) (*types.ContainerState, error) {
 	return &st.ContainerState, nil
 }
+
+func (st NopStrategyTarget) CopyFileFromContainer(_ context.Context, filePath string) (io.ReadCloser, error) {
+	content, ok := st.Files[filePath]
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("could not find the file %s in container", filePath))
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
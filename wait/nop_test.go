@@ -0,0 +1,45 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForNop(t *testing.T) {
+	t.Run("ready immediately", func(t *testing.T) {
+		strategy := ForNop().WithStartupTimeout(time.Second)
+		assert.Equal(t, time.Second, *strategy.Timeout())
+
+		// the target is never used, so it is not polled
+		require.NoError(t, strategy.WaitUntilReady(context.Background(), nil))
+	})
+
+	t.Run("functions run in order until one fails", func(t *testing.T) {
+		var calls []string
+		errNotReady := errors.New("not ready")
+
+		strategy := ForNop(
+			func(_ context.Context, _ StrategyTarget) error {
+				calls = append(calls, "first")
+				return nil
+			},
+			func(_ context.Context, _ StrategyTarget) error {
+				calls = append(calls, "second")
+				return errNotReady
+			},
+			func(_ context.Context, _ StrategyTarget) error {
+				calls = append(calls, "third")
+				return nil
+			},
+		)
+
+		err := strategy.WaitUntilReady(context.Background(), NopStrategyTarget{})
+		require.ErrorIs(t, err, errNotReady)
+		assert.Equal(t, []string{"first", "second"}, calls)
+	})
+}
@@ -27,6 +27,8 @@ func ForSQL(port nat.Port, driver string, url func(host string, port nat.Port) s
 }
 
 type waitForSql struct {
+	startupDelay
+
 	timeout *time.Duration
 
 	URL            func(host string, port nat.Port) string
@@ -49,6 +51,12 @@ func (w *waitForSql) WithPollInterval(pollInterval time.Duration) *waitForSql {
 	return w
 }
 
+// WithStartupDelay sets the grace delay waited for once the query succeeds
+func (w *waitForSql) WithStartupDelay(delay time.Duration) *waitForSql {
+	w.startupDelay.delay = delay
+	return w
+}
+
 // WithQuery can be used to override the default query used in the strategy.
 func (w *waitForSql) WithQuery(query string) *waitForSql {
 	w.query = query
@@ -59,7 +67,9 @@ func (w *waitForSql) Timeout() *time.Duration {
 	return w.timeout
 }
 
-// WaitUntilReady repeatedly tries to run "SELECT 1" or user defined query on the given port using sql and driver.
+// WaitUntilReady repeatedly tries to ping the database and run "SELECT 1" or user defined query on the given port using sql and driver.
+// Any error returned by the driver, e.g. connection refused while the database is starting up, is retried.
+// The driver must be registered by the caller, usually importing it for its side effects.
 //
 // If it doesn't succeed until the timeout value which defaults to 60 seconds, it will return an error.
 func (w *waitForSql) WaitUntilReady(ctx context.Context, target StrategyTarget) (err error) {
@@ -71,6 +81,8 @@ func (w *waitForSql) WaitUntilReady(ctx context.Context, target StrategyTarget)
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	start, attempts := time.Now(), 0
+
 	host, err := target.Host(ctx)
 	if err != nil {
 		return
@@ -85,8 +97,9 @@ func (w *waitForSql) WaitUntilReady(ctx context.Context, target StrategyTarget)
 	for port == "" {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("%s:%w", ctx.Err(), err)
+			return newStartupTimeoutError(fmt.Errorf("%s:%w", ctx.Err(), err), start, attempts)
 		case <-ticker.C:
+			attempts++
 			port, err = target.MappedPort(ctx, w.Port)
 		}
 	}
@@ -96,16 +109,26 @@ func (w *waitForSql) WaitUntilReady(ctx context.Context, target StrategyTarget)
 		return fmt.Errorf("sql.Open: %v", err)
 	}
 	defer db.Close()
+
+	// the last error returned by the driver, e.g. connection refused while the database is starting up
+	var lastErr error
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			if lastErr != nil {
+				return newStartupTimeoutError(fmt.Errorf("%w: %v", ctx.Err(), lastErr), start, attempts)
+			}
+			return newStartupTimeoutError(ctx.Err(), start, attempts)
 		case <-ticker.C:
+			attempts++
 
-			if _, err := db.ExecContext(ctx, w.query); err != nil {
+			if lastErr = db.PingContext(ctx); lastErr != nil {
+				continue
+			}
+			if _, lastErr = db.ExecContext(ctx, w.query); lastErr != nil {
 				continue
 			}
-			return nil
+			return w.waitStartupDelay(ctx)
 		}
 	}
 }
@@ -1,11 +1,62 @@
 package wait
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/docker/go-connections/nat"
 )
 
+// fakeSQLDriver refuses the first connections, as a database which is still starting up
+type fakeSQLDriver struct {
+	failures int32
+	opened   int32
+}
+
+func (d *fakeSQLDriver) Open(_ string) (driver.Conn, error) {
+	if atomic.AddInt32(&d.opened, 1) <= d.failures {
+		return nil, errors.New("connection refused")
+	}
+	return fakeSQLConn{}, nil
+}
+
+type fakeSQLConn struct{}
+
+func (c fakeSQLConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c fakeSQLConn) Close() error {
+	return nil
+}
+
+func (c fakeSQLConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c fakeSQLConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+var (
+	fakeSQLDriverStartingUp = &fakeSQLDriver{failures: 3}
+	fakeSQLDriverDown       = &fakeSQLDriver{failures: 1 << 30}
+)
+
+func init() {
+	sql.Register("fakesql-starting-up", fakeSQLDriverStartingUp)
+	sql.Register("fakesql-down", fakeSQLDriverDown)
+}
+
+func fakeSQLURL(host string, port nat.Port) string {
+	return host + ":" + port.Port()
+}
+
 func Test_waitForSql_WithQuery(t *testing.T) {
 	t.Run("default query", func(t *testing.T) {
 		w := ForSQL("5432/tcp", "postgres", func(host string, port nat.Port) string {
@@ -28,3 +79,30 @@ func Test_waitForSql_WithQuery(t *testing.T) {
 		}
 	})
 }
+
+func Test_waitForSql_RetriesUntilTheDatabaseIsReady(t *testing.T) {
+	w := ForSQL("5432/tcp", "fakesql-starting-up", fakeSQLURL).
+		WithStartupTimeout(5 * time.Second).
+		WithPollInterval(10 * time.Millisecond)
+
+	if err := w.WaitUntilReady(context.Background(), NopStrategyTarget{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&fakeSQLDriverStartingUp.opened); got <= 3 {
+		t.Fatalf("expected more than 3 connection attempts, got %d", got)
+	}
+}
+
+func Test_waitForSql_ReportsTheLastError(t *testing.T) {
+	w := ForSQL("5432/tcp", "fakesql-down", fakeSQLURL).
+		WithStartupTimeout(200 * time.Millisecond).
+		WithPollInterval(10 * time.Millisecond)
+
+	err := w.WaitUntilReady(context.Background(), NopStrategyTarget{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline exceeded error, got %v", err)
+	}
+	if want := "context deadline exceeded: connection refused"; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err)
+	}
+}
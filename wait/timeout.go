@@ -0,0 +1,46 @@
+package wait
+
+import "time"
+
+// StartupTimeoutError is returned by the wait strategies when the container is not ready
+// before the startup timeout, or the context, expires. Its message is the one of the wrapped error,
+// while Elapsed and Attempts tell how long the strategy waited and how many times it checked the container.
+//
+// For Example:
+//
+//	var timeoutErr *wait.StartupTimeoutError
+//	if errors.As(err, &timeoutErr) {
+//		t.Logf("not ready after %s and %d attempts", timeoutErr.Elapsed(), timeoutErr.Attempts())
+//	}
+type StartupTimeoutError struct {
+	err      error
+	elapsed  time.Duration
+	attempts int
+}
+
+func newStartupTimeoutError(err error, start time.Time, attempts int) *StartupTimeoutError {
+	return &StartupTimeoutError{
+		err:      err,
+		elapsed:  time.Since(start),
+		attempts: attempts,
+	}
+}
+
+func (e *StartupTimeoutError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error, usually context.DeadlineExceeded
+func (e *StartupTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// Elapsed returns the time spent waiting for the container
+func (e *StartupTimeoutError) Elapsed() time.Duration {
+	return e.elapsed
+}
+
+// Attempts returns the number of times the container was checked
+func (e *StartupTimeoutError) Attempts() int {
+	return e.attempts
+}
@@ -29,6 +29,12 @@ type StrategyTarget interface {
 	State(context.Context) (*types.ContainerState, error)
 }
 
+// FileStrategyTarget is implemented by the StrategyTarget which files can be copied from, as required by ForFile.
+// It is apart from StrategyTarget, so that the targets implemented outside of this package keep compiling
+type FileStrategyTarget interface {
+	CopyFileFromContainer(ctx context.Context, filePath string) (io.ReadCloser, error)
+}
+
 func defaultStartupTimeout() time.Duration {
 	return 60 * time.Second
 }
@@ -36,3 +42,31 @@ func defaultStartupTimeout() time.Duration {
 func defaultPollInterval() time.Duration {
 	return 100 * time.Millisecond
 }
+
+// sleepContext pauses a polling loop for the given interval, returning early when the context is done
+func sleepContext(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// startupDelay is embedded by the wait strategies, which can wait for a grace delay once their condition is met,
+// set with their WithStartupDelay builder: some services accept connections a moment before they are able to serve
+// requests. The delay is part of the startup timeout of the strategy
+type startupDelay struct {
+	delay time.Duration
+}
+
+// waitStartupDelay waits for the delay, returning the error of the context when it is done before the delay elapses
+func (d startupDelay) waitStartupDelay(ctx context.Context) error {
+	if d.delay <= 0 {
+		return nil
+	}
+
+	sleepContext(ctx, d.delay)
+	return ctx.Err()
+}
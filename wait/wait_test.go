@@ -0,0 +1,131 @@
+package wait
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestWaitUntilReadyHonorsContextCancellation(t *testing.T) {
+	const (
+		startupTimeout = 30 * time.Second
+		pollInterval   = 10 * time.Second
+	)
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		target   StrategyTarget
+	}{
+		{
+			name: "exec",
+			strategy: ForExec([]string{"true"}).
+				WithExitCodeMatcher(func(int) bool { return false }).
+				WithStartupTimeout(startupTimeout).
+				WithPollInterval(pollInterval),
+			target: NopStrategyTarget{},
+		},
+		{
+			name:     "exit",
+			strategy: ForExit().WithExitTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{ContainerState: types.ContainerState{Running: true}},
+		},
+		{
+			name:     "file",
+			strategy: ForFile("/tmp/ready").WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{},
+		},
+		{
+			name:     "health",
+			strategy: ForHealthCheck().WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{ContainerState: types.ContainerState{Health: &types.Health{Status: types.Starting}}},
+		},
+		{
+			name:     "host port",
+			strategy: ForListeningPort(closedPort(t)).WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{},
+		},
+		{
+			name:     "http",
+			strategy: ForHTTP("/").WithPort(closedPort(t)).WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{},
+		},
+		{
+			name:     "log",
+			strategy: ForLog("ready").WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{ReaderCloser: io.NopCloser(bytes.NewReader([]byte("starting")))},
+		},
+		{
+			name:     "multi",
+			strategy: ForAll(ForLog("ready").WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval)),
+			target:   NopStrategyTarget{ReaderCloser: io.NopCloser(bytes.NewReader([]byte("starting")))},
+		},
+		{
+			name:     "sql",
+			strategy: ForSQL("5432/tcp", "fakesql-down", fakeSQLURL).WithStartupTimeout(startupTimeout).WithPollInterval(pollInterval),
+			target:   NopStrategyTarget{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(200*time.Millisecond, cancel)
+			defer cancel()
+
+			start := time.Now()
+			err := tt.strategy.WaitUntilReady(ctx, tt.target)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected the context cancellation to be returned, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("expected the strategy to return as soon as the context is cancelled, returned after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestWaitUntilReadyWithStartupDelay(t *testing.T) {
+	const startupDelay = 300 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+	}{
+		{name: "exit", strategy: ForExit().WithStartupDelay(startupDelay)},
+		{name: "log", strategy: ForLog("ready").WithStartupDelay(startupDelay)},
+		{name: "multi", strategy: ForAll(ForLog("ready")).WithStartupDelay(startupDelay)},
+		{name: "nop", strategy: ForNop().WithStartupDelay(startupDelay)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := NopStrategyTarget{ReaderCloser: io.NopCloser(bytes.NewReader([]byte("ready")))}
+
+			start := time.Now()
+			if err := tt.strategy.WaitUntilReady(context.Background(), target); err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed < startupDelay {
+				t.Fatalf("expected the strategy to wait for the startup delay, returned after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestWaitUntilReadyWithStartupDelayLongerThanTheStartupTimeout(t *testing.T) {
+	target := NopStrategyTarget{ReaderCloser: io.NopCloser(bytes.NewReader([]byte("ready")))}
+	wg := ForLog("ready").
+		WithStartupTimeout(200 * time.Millisecond).
+		WithStartupDelay(10 * time.Second)
+
+	err := wg.WaitUntilReady(context.Background(), target)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the startup timeout to be exceeded, got %v", err)
+	}
+}